@@ -5,6 +5,8 @@
 package main
 
 import (
+	"os"
+
 	"github.com/DazFather/parrbot/message" // (Core) Incoming / Outgoing message-related
 	"github.com/DazFather/parrbot/robot"   // (Core) Parr(B)ot core functionality
 	"github.com/DazFather/parrbot/tgui"    // (Utility) Toolkit for UI
@@ -23,8 +25,10 @@ func main() {
 		// UseMenu method will generate the needed command for the using the menu
 		tgui.UseMenu(helpHandler, "/help", "Help menu"),
 	}
-	// Make the bot alive
-	robot.Start(commandList...)
+	// Make the bot alive, the error (if any) is already logged
+	if err := robot.Start(commandList...); err != nil {
+		os.Exit(1)
+	}
 }
 
 // every robot.CommandFunc can return a new message to be sent, to better organize your code
@@ -0,0 +1,156 @@
+package message
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// FailureReason tells why a message could not be delivered to a chat during a broadcast
+type FailureReason uint8
+
+const (
+	OTHER_FAILURE    FailureReason = iota // Any other error, see BroadcastFailure.Err
+	BOT_BLOCKED                           // The user blocked the bot, or the bot has been kicked from the chat
+	USER_DEACTIVATED                      // The account of the user has been deleted
+	CHAT_NOT_FOUND                        // The chat does not exist or the bot never interacted with it
+)
+
+// String returns a human readable description of the reason
+func (reason FailureReason) String() string {
+	switch reason {
+	case BOT_BLOCKED:
+		return "bot blocked"
+	case USER_DEACTIVATED:
+		return "user deactivated"
+	case CHAT_NOT_FOUND:
+		return "chat not found"
+	}
+	return "other failure"
+}
+
+// failureReason classifies the error returned by Telegram when sending a message
+func failureReason(err error) FailureReason {
+	var resErr *ResponseError
+	if !errors.As(err, &resErr) || resErr.From != "Telegram" {
+		return OTHER_FAILURE
+	}
+
+	switch desc := strings.ToLower(resErr.Description); {
+	case strings.Contains(desc, "deactivated"):
+		return USER_DEACTIVATED
+	case strings.Contains(desc, "blocked"), strings.Contains(desc, "kicked"):
+		return BOT_BLOCKED
+	case strings.Contains(desc, "chat not found"):
+		return CHAT_NOT_FOUND
+	}
+	return OTHER_FAILURE
+}
+
+// BroadcastOptions contains the optional parameters of Broadcast
+type BroadcastOptions struct {
+	Concurrency int                     // Max number of messages sent at the same time, by default 8
+	OnProgress  func(BroadcastProgress) // Called (one call at a time) after every chat has been handled
+}
+
+// BroadcastProgress is the state of a running broadcast
+type BroadcastProgress struct {
+	Sent   int // Number of chats that received the message
+	Failed int // Number of chats that did not receive the message
+	Total  int // Number of chats to handle, -1 if unknown (see BroadcastChan)
+}
+
+// BroadcastFailure is a chat that did not receive the broadcast message
+type BroadcastFailure struct {
+	ChatID int64
+	Reason FailureReason
+	Err    error
+}
+
+// BroadcastReport is the result of a broadcast
+type BroadcastReport struct {
+	Sent     int                // Number of chats that received the message
+	Failures []BroadcastFailure // Chats that did not receive the message and why
+}
+
+// Failed returns the IDs of the chats that did not receive the message for the
+// given reason, useful to remove blocked or deactivated users
+func (report BroadcastReport) Failed(reason FailureReason) (chatIDs []int64) {
+	for _, failure := range report.Failures {
+		if failure.Reason == reason {
+			chatIDs = append(chatIDs, failure.ChatID)
+		}
+	}
+	return
+}
+
+// Broadcast sends the message to all the given chats and returns a report of
+// the chats that failed. The rate limits (see SetRateLimits) are respected
+func Broadcast(msg Any, chatIDs []int64, opts *BroadcastOptions) BroadcastReport {
+	return defaultClient.Broadcast(msg, chatIDs, opts)
+}
+
+// BroadcastChan works as Broadcast but the chat IDs are received from the given
+// channel, until it's closed. Useful when the chats are loaded little by little
+func BroadcastChan(msg Any, chatIDs <-chan int64, opts *BroadcastOptions) BroadcastReport {
+	return defaultClient.BroadcastChan(msg, chatIDs, opts)
+}
+
+// Broadcast works as the Broadcast function but the messages are sent using the client
+func (c *Client) Broadcast(msg Any, chatIDs []int64, opts *BroadcastOptions) BroadcastReport {
+	var ids = make(chan int64)
+	go func() {
+		defer close(ids)
+		for _, chatID := range chatIDs {
+			ids <- chatID
+		}
+	}()
+	return c.broadcast(msg, ids, len(chatIDs), opts)
+}
+
+// BroadcastChan works as the BroadcastChan function but the messages are sent using the client
+func (c *Client) BroadcastChan(msg Any, chatIDs <-chan int64, opts *BroadcastOptions) BroadcastReport {
+	return c.broadcast(msg, chatIDs, -1, opts)
+}
+
+func (c *Client) broadcast(msg Any, chatIDs <-chan int64, total int, opts *BroadcastOptions) (report BroadcastReport) {
+	if opts == nil {
+		opts = new(BroadcastOptions)
+	}
+	var workers = opts.Concurrency
+	if workers <= 0 {
+		workers = 8
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	handled := func(chatID int64, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			report.Failures = append(report.Failures, BroadcastFailure{chatID, failureReason(err), err})
+		} else {
+			report.Sent++
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(BroadcastProgress{report.Sent, len(report.Failures), total})
+		}
+	}
+
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for chatID := range chatIDs {
+				_, err := c.Send(msg, chatID)
+				handled(chatID, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return
+}
@@ -0,0 +1,85 @@
+package message_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/DazFather/parrbot/message"
+)
+
+// fakeMessage is a message that fails to be sent on negative chat IDs
+type fakeMessage struct{}
+
+func (fakeMessage) Send(chatID int64) (*message.UpdateMessage, error) {
+	if chatID < 0 {
+		return nil, errors.New("unable to send")
+	}
+	return nil, nil
+}
+
+func ExampleBroadcast() {
+	report := message.Broadcast(fakeMessage{}, []int64{1, -2, 3}, &message.BroadcastOptions{
+		Concurrency: 1,
+		OnProgress: func(progress message.BroadcastProgress) {
+			fmt.Println(progress.Sent+progress.Failed, "/", progress.Total)
+		},
+	})
+
+	fmt.Println("sent:", report.Sent)
+	for _, failure := range report.Failures {
+		fmt.Println("failed:", failure.ChatID, failure.Reason, failure.Err)
+	}
+	// Output:
+	// 1 / 3
+	// 2 / 3
+	// 3 / 3
+	// sent: 2
+	// failed: -2 other failure unable to send
+}
+
+// failingMessage is a message that fails to be sent with the error of its chat
+type failingMessage map[int64]error
+
+func (m failingMessage) Send(chatID int64) (*message.UpdateMessage, error) {
+	return nil, m[chatID]
+}
+
+func TestBroadcastFailures(t *testing.T) {
+	msg := failingMessage{
+		2: &message.ResponseError{From: "Telegram", ErrorCode: 403, Description: "Forbidden: bot was blocked by the user"},
+		3: &message.ResponseError{From: "Telegram", ErrorCode: 403, Description: "Forbidden: bot was kicked from the group chat"},
+		4: &message.ResponseError{From: "Telegram", ErrorCode: 403, Description: "Forbidden: user is deactivated"},
+		5: &message.ResponseError{From: "Telegram", ErrorCode: 400, Description: "Bad Request: chat not found"},
+		6: &message.ResponseError{From: "Telegram", ErrorCode: 400, Description: "Bad Request: message text is empty"},
+		7: &message.ResponseError{From: "Echotron", ErrorCode: 1, Description: "chat not found"},
+	}
+	report := message.Broadcast(msg, []int64{1, 2, 3, 4, 5, 6, 7}, nil)
+
+	if report.Sent != 1 {
+		t.Errorf("sent to %d chats, want 1", report.Sent)
+	}
+	reasons := make(map[int64]message.FailureReason)
+	for _, failure := range report.Failures {
+		reasons[failure.ChatID] = failure.Reason
+		if failure.Err != msg[failure.ChatID] {
+			t.Errorf("chat %d failed with %v, want %v", failure.ChatID, failure.Err, msg[failure.ChatID])
+		}
+	}
+	want := map[int64]message.FailureReason{
+		2: message.BOT_BLOCKED,
+		3: message.BOT_BLOCKED,
+		4: message.USER_DEACTIVATED,
+		5: message.CHAT_NOT_FOUND,
+		6: message.OTHER_FAILURE,
+		7: message.OTHER_FAILURE,
+	}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("failure reasons = %v, want %v", reasons, want)
+	}
+
+	if blocked := report.Failed(message.BOT_BLOCKED); len(blocked) != 2 {
+		t.Errorf("Failed(BOT_BLOCKED) = %v, want chats 2 and 3", blocked)
+	}
+}
@@ -15,6 +15,8 @@ type CallbackQuery struct {
 	ChatInstance    string         `json:"chat_instance,omitempty"`
 	Data            string         `json:"data,omitempty"`
 	GameShortName   string         `json:"game_short_name,omitempty"`
+
+	client *Client // client that received the callback query
 }
 
 // AnswerAlert allows to reply to a given callback with a _ notification.
@@ -40,7 +42,8 @@ func (callback CallbackQuery) AnswerToast(text string, cacheTime uint16) error {
 
 // Answer allows to reply to a given callback using given options
 func (callback CallbackQuery) Answer(opts *echotron.CallbackQueryOptions) error {
-	return parseResponseError(api.AnswerCallbackQuery(callback.ID, opts))
+	var res echotron.APIResponseBool
+	return callback.client.orDefault().do(newRequest("answerCallbackQuery").set("callback_query_id", callback.ID).with(opts), &res)
 }
 
 // EditText is a method that allows to edit the text (and others options)
@@ -0,0 +1,162 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// Client sends the requests to Telegram on behalf of a bot, with its own token
+// and rate limits. The functions and methods of this package use the default
+// client (see LoadAPI), unless the update or message they are called on has
+// been received or sent by another client
+type Client struct {
+	api       echotron.API
+	token     string
+	limiter   *rateLimiter
+	observer  CallObserver
+	logger    Logger
+	mu        sync.RWMutex
+	transport http.RoundTripper
+}
+
+// CallObserver receives the outcome of a request made by a Client to Telegram:
+// the name of the Bot API method (ex. "sendMessage"), how long it took and the
+// error (as *ResponseError), nil if successful. See Client.Observe
+type CallObserver func(method string, elapsed time.Duration, err error)
+
+// defaultClient is the client used when no other is specified
+var defaultClient = &Client{limiter: newRateLimiter(DefaultRateLimits)}
+
+// NewClient creates a new Client for the bot with the given token, using the
+// DefaultRateLimits. Useful to run more bots on the same program
+func NewClient(token string) *Client {
+	return &Client{api: echotron.NewAPI(token), token: token, limiter: newRateLimiter(DefaultRateLimits)}
+}
+
+// DefaultClient returns the client used by the functions of this package, set by LoadAPI
+func DefaultClient() *Client {
+	return defaultClient
+}
+
+// orDefault returns the client itself, or the default one if nil
+func (c *Client) orDefault() *Client {
+	if c == nil {
+		return defaultClient
+	}
+	return c
+}
+
+// API returns an echotron.API with the token of the client, useful for
+// compatibility with not yet supported echotron functions calls. Keep in mind
+// that its requests don't use the transport of the client (see SetTransport)
+func (c *Client) API() echotron.API {
+	return c.orDefault().api
+}
+
+// SetRateLimits changes the rate limits of the messages sent by the client, use
+// nil to disable them (even the retries after a 429 Too Many Requests error)
+func (c *Client) SetRateLimits(limits *RateLimits) {
+	if limits == nil {
+		c.limiter = nil
+		return
+	}
+	c.limiter = newRateLimiter(*limits)
+}
+
+// SetLogger changes where the client writes what goes wrong (ex. the updates
+// that can't be casted), use nil to restore the default StdLogger
+func (c *Client) SetLogger(logger Logger) {
+	c.logger = logger
+}
+
+// Logger returns the logger of the client
+func (c *Client) Logger() Logger {
+	if c = c.orDefault(); c.logger != nil {
+		return c.logger
+	}
+	return StdLogger()
+}
+
+// Observe makes the client call fn after every request made to send, edit or
+// delete messages and to answer callback queries, including the retries. Useful
+// to collect metrics. Use nil to stop observing
+func (c *Client) Observe(fn CallObserver) {
+	c.observer = fn
+}
+
+// observe passes the outcome of a request, started at the given time, to the
+// observer of the client (if any) and returns the error
+func (c *Client) observe(method string, start time.Time, err error) error {
+	if c.observer != nil {
+		c.observer(method, time.Since(start), err)
+	}
+	return err
+}
+
+// requester is implemented by the outgoing messages of this package, to send
+// them using any client
+type requester interface {
+	request(chatID int64) *apiRequest
+}
+
+// Send sends the message to the given chat using the client. Messages that are
+// not declared in this package will be sent using their own Send method
+func (c *Client) Send(msg Any, chatID int64) (*UpdateMessage, error) {
+	c = c.orDefault()
+
+	r, ok := msg.(requester)
+	if !ok {
+		return msg.Send(chatID)
+	}
+
+	req := r.request(chatID)
+	res, err := limited(c, chatID, func() (res echotron.APIResponseMessage, err error) {
+		err = c.do(req, &res)
+		return
+	})
+	return clearResponse(c, res, err)
+}
+
+// CastUpdate works as the CastUpdate function but the update (and the messages
+// it contains) will remember the client, so that replies, edits and answers to
+// callback queries will be made by it. See Update.Client
+func (c *Client) CastUpdate(original *echotron.Update) *Update {
+	return castUpdate(c.orDefault(), original)
+}
+
+// Call makes a request to the given method of the Bot API, useful for the ones
+// that echotron doesn't support or doesn't serialize as Telegram expects. The
+// result is decoded into result, if not nil. As for the other requests of the
+// client it's observed, respects the global rate limit and the error is a *ResponseError
+func (c *Client) Call(method string, params url.Values, result interface{}) error {
+	return c.CallContext(context.Background(), method, params, result)
+}
+
+// CallContext works as Call, but the request is cancelled when ctx is done
+func (c *Client) CallContext(ctx context.Context, method string, params url.Values, result interface{}) error {
+	c = c.orDefault()
+
+	var res struct {
+		echotron.APIResponseBase
+		Result json.RawMessage `json:"result"`
+	}
+	_, err := limited(c, 0, func() (echotron.APIResponseBase, error) {
+		start := time.Now()
+		res.Result = nil
+		err := c.observe(method, start, c.post(ctx, &apiRequest{method: method, params: params}, &res))
+		return res.APIResponseBase, err
+	})
+	if err != nil || result == nil {
+		return err
+	}
+	if err = json.Unmarshal(res.Result, result); err != nil {
+		return &ResponseError{"Parrbot", 1, "Unable to decode the result of " + method + ": " + err.Error()}
+	}
+	return nil
+}
@@ -3,16 +3,26 @@ package message
 import (
 	"encoding/json"
 	"fmt"
+	"html"
 	"strings"
 	"time"
 
 	"github.com/NicoNex/echotron/v3"
 )
 
+// logValueLimit is the max number of characters of each part of a value shown by Log,
+// so that the message doesn't exceed the Telegram's limit
+const logValueLimit = 1000
+
 // Log is a useful function to show what values the data is carrying using JSON.
 // Tips: Be careful to who you are sending the message or the end user could be
 // a bit confused. If you are the developer use your own chatID
 func Log(chatID int64, any ...interface{}) {
+	defaultClient.Log(chatID, any...)
+}
+
+// Log works as the Log function but the message is sent using the client
+func (c *Client) Log(chatID int64, any ...interface{}) {
 	var message = Text{
 		fmt.Sprint("🦜 <b>Log</b> [", time.Now(), "]\n"),
 		&echotron.MessageOptions{ParseMode: echotron.HTML},
@@ -20,9 +30,9 @@ func Log(chatID int64, any ...interface{}) {
 
 	// Parsing each data and add the result to the message text
 	for i, value := range any {
-		t := fmt.Sprint("\n<b>Data (", i, "):</b>\nString: <code>", strings.ReplaceAll(fmt.Sprint(value), "<nil>", "nil"), "</code>")
+		t := fmt.Sprint("\n<b>Data (", i, "):</b>\nString: <code>", logEscape(strings.ReplaceAll(fmt.Sprint(value), "<nil>", "nil")), "</code>")
 		if data, e := json.MarshalIndent(value, "", "   "); e == nil {
-			message.Text += fmt.Sprint(t, "\nJSON:\n<code>", string(data), "</code>\n")
+			message.Text += fmt.Sprint(t, "\nJSON:\n<code>", logEscape(string(data)), "</code>\n")
 		} else {
 			message.Text += fmt.Sprint(t, "\n<code>[Impossible to parse JSON]</code>\n")
 		}
@@ -42,5 +52,14 @@ func Log(chatID int64, any ...interface{}) {
 	})
 
 	// Send the message to the specified user
-	message.Send(chatID)
+	c.Send(message, chatID)
+}
+
+// logEscape escapes the given text to be shown inside an HTML message by Log,
+// truncating it if too long
+func logEscape(text string) string {
+	if runes := []rune(text); len(runes) > logValueLimit {
+		text = string(runes[:logValueLimit]) + "…"
+	}
+	return html.EscapeString(text)
 }
@@ -12,17 +12,20 @@ type editable interface {
 	grabMessage() *UpdateMessage
 }
 
-type editFn func(echotron.MessageIDOptions) (echotron.APIResponseMessage, error)
-
-func edit(e editable, call editFn) (err error) {
+func edit(e editable, req *apiRequest) (err error) {
 	var msgID = e.extractID()
 	if msgID == nil {
 		return errors.New("Invalid message or Id")
 	}
 
 	// Perform the edit and clearig the response
-	var edited *UpdateMessage
-	edited, err = clearResponse(call(*msgID))
+	var (
+		edited *UpdateMessage
+		client = clientOf(e)
+		res    echotron.APIResponseMessage
+	)
+	err = client.do(req.with(*msgID), &res)
+	edited, err = clearResponse(client, res, err)
 	if err != nil || edited != nil {
 		return
 	}
@@ -40,35 +43,25 @@ func edit(e editable, call editFn) (err error) {
 }
 
 func editText(e editable, text string, opts *echotron.MessageTextOptions) error {
-	return edit(e, func(msgID echotron.MessageIDOptions) (echotron.APIResponseMessage, error) {
-		return api.EditMessageText(text, *e.extractID(), opts)
-	})
+	return edit(e, newRequest("editMessageText").set("text", text).with(opts))
 }
 
 func editMedia(e editable, media echotron.InputMedia, opts *echotron.MessageReplyMarkup) error {
-	return edit(e, func(msgID echotron.MessageIDOptions) (echotron.APIResponseMessage, error) {
-		return api.EditMessageMedia(*e.extractID(), media, opts)
-	})
+	return edit(e, newRequest("editMessageMedia").media(media).with(opts))
 }
 
 func editInlineKbd(e editable, keyboard [][]echotron.InlineKeyboardButton) error {
 	var opts = &echotron.MessageReplyMarkup{ReplyMarkup: echotron.InlineKeyboardMarkup{InlineKeyboard: keyboard}}
 
-	return edit(e, func(msgID echotron.MessageIDOptions) (echotron.APIResponseMessage, error) {
-		return api.EditMessageReplyMarkup(*e.extractID(), opts)
-	})
+	return edit(e, newRequest("editMessageReplyMarkup").with(opts))
 }
 
 func editLiveLocation(e editable, latitude, longitude float64, opts *echotron.EditLocationOptions) error {
-	return edit(e, func(msgID echotron.MessageIDOptions) (echotron.APIResponseMessage, error) {
-		return api.EditMessageLiveLocation(*e.extractID(), latitude, longitude, opts)
-	})
+	return edit(e, newRequest("editMessageLiveLocation").set("latitude", latitude).set("longitude", longitude).with(opts))
 }
 
 func editCaption(e editable, opts *echotron.MessageCaptionOptions) error {
-	return edit(e, func(msgID echotron.MessageIDOptions) (echotron.APIResponseMessage, error) {
-		return api.EditMessageCaption(*e.extractID(), opts)
-	})
+	return edit(e, newRequest("editMessageCaption").with(opts))
 }
 
 func delete(e editable) error {
@@ -81,7 +74,25 @@ func delete(e editable) error {
 	}
 
 	// Deleting message and clearing response
-	return parseResponseError(api.DeleteMessage(message.Chat.ID, message.ID))
+	var res echotron.APIResponseBool
+	return clientOf(e).do(newRequest("deleteMessage").set("chat_id", message.Chat.ID).set("message_id", message.ID), &res)
+}
+
+// clientOf returns the client that received or sent the editable
+func clientOf(e editable) (c *Client) {
+	switch e := e.(type) {
+	case *UpdateMessage:
+		if e != nil {
+			c = e.client
+		}
+	case CallbackQuery:
+		c = e.client
+	case Update:
+		c = e.client
+	case Reference:
+		c = e.client
+	}
+	return c.orDefault()
 }
 
 /* --- Implementing UpdateMessage --- */
@@ -2,7 +2,6 @@ package message
 
 import (
 	"encoding/json"
-	"log"
 	"unicode/utf16"
 
 	"github.com/NicoNex/echotron/v3"
@@ -41,18 +40,23 @@ type UpdateMessage struct {
 	 */
 	Text     string                    `json:"text,omitempty"`
 	Entities []*echotron.MessageEntity `json:"entities,omitempty"`
+
+	client *Client // client that received or sent the message
 }
 
-// castMessage transform an *echotron.Message into a *UpdateMessage
-func castMessage(original *echotron.Message) (message *UpdateMessage) {
+// castMessage transform an *echotron.Message into a *UpdateMessage bound to the
+// given client. If the message can't be casted the error is logged and nil is returned
+func castMessage(c *Client, original *echotron.Message) (message *UpdateMessage) {
 	if original == nil { // Guard close
 		return nil
 	}
 
 	// Util function for error checking
+	var failed bool
 	check := func(e error) {
-		if e != nil {
-			log.Fatal(e)
+		if e != nil && !failed {
+			c.Logger().Error("Unable to cast message", "chat_id", original.Chat.ID, "message_id", original.ID, "error", e)
+			failed = true
 		}
 	}
 
@@ -61,10 +65,10 @@ func castMessage(original *echotron.Message) (message *UpdateMessage) {
 	check(err)
 
 	// Copy common values to the new message...
-	message = new(UpdateMessage)
+	message = &UpdateMessage{client: c}
 	check(json.Unmarshal(jsonData, message))
 	if original.ReplyToMessage != nil {
-		message.ReplyToMessage = castMessage(original.ReplyToMessage)
+		message.ReplyToMessage = castMessage(c, original.ReplyToMessage)
 	}
 
 	// ... values if the message is forwarded
@@ -85,7 +89,7 @@ func castMessage(original *echotron.Message) (message *UpdateMessage) {
 	SystemMsg := SystemNotificationInfo{}
 	check(json.Unmarshal(jsonData, &SystemMsg))
 	if original.PinnedMessage != nil {
-		SystemMsg.PinnedMessage = castMessage(original.PinnedMessage)
+		SystemMsg.PinnedMessage = castMessage(c, original.PinnedMessage)
 		message.SystemNotification = &SystemMsg
 	} else if b, _ := json.Marshal(SystemMsg); len(b) > 2 {
 		message.SystemNotification = &SystemMsg
@@ -97,6 +101,9 @@ func castMessage(original *echotron.Message) (message *UpdateMessage) {
 		message.Entities = original.CaptionEntities
 	}
 
+	if failed {
+		return nil
+	}
 	return
 }
 
@@ -0,0 +1,58 @@
+package message
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Logger is where the framework writes what happens, along with structured
+// fields given as alternated keys and values (ex. "chat_id", 42). It has the
+// same methods of *slog.Logger, so it can be used directly
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// StdLogger returns a Logger that writes on the standard log package, in the
+// form: LEVEL message key=value key=value. Debug messages are discarded
+func StdLogger() Logger {
+	return stdLogger{}
+}
+
+// stdLogger is the Logger returned by StdLogger
+type stdLogger struct{}
+
+func (stdLogger) Debug(msg string, args ...any) {}
+
+func (stdLogger) Info(msg string, args ...any) {
+	log.Print(format("INFO", msg, args))
+}
+
+func (stdLogger) Warn(msg string, args ...any) {
+	log.Print(format("WARN", msg, args))
+}
+
+func (stdLogger) Error(msg string, args ...any) {
+	log.Print(format("ERROR", msg, args))
+}
+
+// format writes the level, the message and the fields in a single line
+func format(level, msg string, args []any) string {
+	var b strings.Builder
+	b.WriteString(level + " " + msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			fmt.Fprintf(&b, " !BADKEY=%v", args[i])
+			break
+		}
+		value := fmt.Sprint(args[i+1])
+		if strings.ContainsAny(value, " \"=\n") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, " %v=%s", args[i], value)
+	}
+	return b.String()
+}
@@ -28,68 +28,91 @@ type MediaInfo struct {
 	Location        *echotron.Location        `json:"location,omitempty"`
 }
 
-// ExtractFileID try to extracts the FileID from the Info of a media
-func (m MediaInfo) ExtractFileID() (id *FileID) {
+// ExtractFileID try to extracts the FileID from the Info of a media, nil if
+// there is no media with a file
+func (m MediaInfo) ExtractFileID() *FileID {
+	var id FileID
 	switch {
 	case m.Animation != nil:
-		*id = GrabAnimationFileID(m.Animation)
+		id = GrabAnimationFileID(m.Animation)
 	case m.Audio != nil:
-		*id = GrabAudioFileID(m.Audio)
+		id = GrabAudioFileID(m.Audio)
 	case m.Document != nil:
-		*id = GrabDocumentFileID(m.Document)
+		id = GrabDocumentFileID(m.Document)
 	case m.Photo != nil && len(m.Photo) > 0:
-		*id = GrabPhotoFileID(m.Photo[len(m.Photo)-1])
+		id = GrabPhotoFileID(m.Photo[len(m.Photo)-1])
 	case m.Sticker != nil:
-		*id = GrabStickerFileID(m.Sticker)
+		id = GrabStickerFileID(m.Sticker)
 	case m.Video != nil:
-		*id = GrabVideoFileID(m.Video)
+		id = GrabVideoFileID(m.Video)
 	case m.VideoNote != nil:
-		*id = GrabVideoNoteFileID(m.VideoNote)
+		id = GrabVideoNoteFileID(m.VideoNote)
 	case m.Voice != nil:
-		*id = GrabVoiceFileID(m.Voice)
+		id = GrabVoiceFileID(m.Voice)
+	default:
+		return nil
 	}
 
-	return
+	return &id
 }
 
-// FileID is the identifier of a sent or recived File of any kind
+// FileID is the identifier of a sent or recived File of any kind. File IDs
+// are valid only for the bot that received them, so when running more bots
+// use the methods of its client (see Update.Client), ex. client.FetchFile(id)
 type FileID string
 
 // RetrieveInfo retrieve the info of a particular file from Telegram servers
+// using the default client
 func (id FileID) RetrieveInfo() (file *echotron.File, err error) {
+	return defaultClient.RetrieveInfo(id)
+}
+
+// FetchFile fetch the file content from Telegram servers using the default client
+func (id FileID) FetchFile() (content []byte, err error) {
+	return defaultClient.FetchFile(id)
+}
+
+// SaveFile downloads a file in the given directory at the same relative path
+// specified by Telegram and returns the complete path where has been saved locally,
+// it's content and error. The default client is used
+func (id FileID) SaveFile(directory string) (filePath string, content []byte, err error) {
+	return defaultClient.SaveFile(id, directory)
+}
+
+// RetrieveInfo works as FileID.RetrieveInfo but using the client
+func (c *Client) RetrieveInfo(id FileID) (file *echotron.File, err error) {
 	var res echotron.APIResponseFile
 
-	res, err = api.GetFile(string(id))
-	err = parseResponseError(res, err)
-	if err == nil {
+	c = c.orDefault()
+	if err = c.do(newRequest("getFile").set("file_id", string(id)), &res); err == nil {
 		file = res.Result
 	}
 	return
 }
 
-// FetchFile fetch the file content from Telegram servers
-func (id FileID) FetchFile() (content []byte, err error) {
+// FetchFile works as FileID.FetchFile but using the client
+func (c *Client) FetchFile(id FileID) (content []byte, err error) {
 	var file *echotron.File
 
-	file, err = id.RetrieveInfo()
-	if err != nil {
+	c = c.orDefault()
+	if file, err = c.RetrieveInfo(id); err != nil {
 		return
 	}
 
-	return api.DownloadFile(file.FilePath)
+	return c.download(file.FilePath)
 }
 
-// SaveFile downloads a file in the given directory at the same relative path
-// specified by Telegram and returns the complete path where has been saved locally,
-// it's content and error
-func (id FileID) SaveFile(directory string) (filePath string, content []byte, err error) {
-	if res, e := id.RetrieveInfo(); e != nil {
-		err = e
-	} else {
-		filePath = res.FilePath
+// SaveFile works as FileID.SaveFile but using the client
+func (c *Client) SaveFile(id FileID, directory string) (filePath string, content []byte, err error) {
+	var file *echotron.File
+
+	c = c.orDefault()
+	if file, err = c.RetrieveInfo(id); err != nil {
+		return
 	}
+	filePath = file.FilePath
 
-	content, err = api.DownloadFile(filePath)
+	content, err = c.download(filePath)
 	if err != nil {
 		return
 	}
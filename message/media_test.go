@@ -0,0 +1,75 @@
+package message_test
+
+import (
+	"testing"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+func TestClientFetchFile(t *testing.T) {
+	var (
+		fetched  []byte
+		fetchErr error
+	)
+	d, err := parrbottest.Start(robot.DefaultConfig(), robot.Command{
+		Trigger: robot.DefaultTrigger,
+		ReplyAt: message.MESSAGE,
+		CallFunc: func(bot *robot.Bot, update *message.Update) message.Any {
+			id := update.Message.Media.ExtractFileID()
+			if id == nil {
+				return message.Text{Text: "no file"}
+			}
+			// File IDs belong to the bot that received them
+			fetched, fetchErr = update.Client().FetchFile(*id)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	file := d.StoreFile("documents/file_1.txt", []byte("hello"))
+	err = d.Update(&echotron.Update{Message: &echotron.Message{
+		From:     &echotron.User{ID: 42},
+		Chat:     echotron.Chat{ID: 42, Type: "private"},
+		Document: &echotron.Document{FileID: file.FileID, FileName: "file.txt"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fetchErr != nil || string(fetched) != "hello" {
+		t.Fatalf("FetchFile = %q, %v, want \"hello\"", fetched, fetchErr)
+	}
+	if call, ok := d.Last(); !ok || call.Method != "getFile" || call.Token != d.Token {
+		t.Errorf("last call = %+v, want getFile made by the token of the robot", call)
+	}
+}
+
+func TestClientRetrieveInfoInvalid(t *testing.T) {
+	d, err := parrbottest.Start(robot.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	if _, err = d.Robot.Client().RetrieveInfo("missing"); err == nil {
+		t.Error("RetrieveInfo of a missing file succeeded")
+	}
+}
+
+func TestExtractFileID(t *testing.T) {
+	if id := (message.MediaInfo{}).ExtractFileID(); id != nil {
+		t.Errorf("ExtractFileID without media = %q, want nil", *id)
+	}
+
+	media := message.MediaInfo{Photo: []*echotron.PhotoSize{{FileID: "small"}, {FileID: "big"}}}
+	if id := media.ExtractFileID(); id == nil || *id != "big" {
+		t.Errorf("ExtractFileID of a photo = %v, want the biggest size", id)
+	}
+}
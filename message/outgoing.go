@@ -1,26 +1,25 @@
 package message
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/NicoNex/echotron/v3"
 )
 
-// api is used by echotron library to send request to Telegram
-var api echotron.API
-
 // LoadAPI resets the api to a new value using given token.
 // Keep in mind that both api and token will be already set douring robot.Start and
 // if you don't want to use program's argument, you can use robot.Config.SetAPIToken
 // intead. You are probably NOT going to need this function
 func LoadAPI(token string) {
-	api = echotron.NewAPI(token)
+	defaultClient.api = echotron.NewAPI(token)
+	defaultClient.token = token
 }
 
 // API return the current api, useful for compatibility with not yet supported
 // echotron functions calls
 func API() echotron.API {
-	return api
+	return defaultClient.api
 }
 
 // ResponseError is an error generated by a echotron / Telegram resonse
@@ -35,7 +34,18 @@ func (err ResponseError) Error() string {
 	return fmt.Sprint("[", err.ErrorCode, "] ", err.From, ": ", err.Description)
 }
 
-func parseResponseError(res echotron.APIResponse, err error) *ResponseError {
+// parseResponseError returns the error (as *ResponseError) of the response, or nil if everything went fine
+func parseResponseError(res echotron.APIResponse, err error) error {
+	var (
+		apiErr *echotron.APIError
+		resErr *ResponseError
+	)
+	if errors.As(err, &resErr) {
+		return resErr
+	}
+	if errors.As(err, &apiErr) {
+		return &ResponseError{"Telegram", apiErr.ErrorCode(), apiErr.Description()}
+	}
 	if err != nil {
 		return &ResponseError{"Echotron", 1, err.Error()}
 	}
@@ -46,14 +56,14 @@ func parseResponseError(res echotron.APIResponse, err error) *ResponseError {
 }
 
 // clearResponse it clears the echotron.APIResponseMessage and returns the actual
-// message of type *UpdateMessage (casting it from Result),
+// message of type *UpdateMessage (casting it from Result, bound to the given client),
 // and an error by checking both, the APIResponseBase and the echotron err
-func clearResponse(res echotron.APIResponseMessage, err error) (*UpdateMessage, error) {
+func clearResponse(c *Client, res echotron.APIResponseMessage, err error) (*UpdateMessage, error) {
 	if e := parseResponseError(res, err); e != nil {
 		return nil, e
 	}
 
-	return castMessage(res.Result), nil
+	return castMessage(c, res.Result), nil
 }
 
 // Any rapresent any single message type with the exeption of MediaGroup
@@ -70,7 +80,12 @@ type Animation struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Animation) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendAnimation(message.File, chatID, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Animation) request(chatID int64) *apiRequest {
+	return newRequest("sendAnimation").set("chat_id", chatID).file("animation", message.File).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -110,7 +125,12 @@ type Audio struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Audio) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendAudio(message.File, chatID, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Audio) request(chatID int64) *apiRequest {
+	return newRequest("sendAudio").set("chat_id", chatID).file("audio", message.File).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -150,7 +170,12 @@ type Contact struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Contact) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendContact(message.PhoneNumber, message.FirstName, chatID, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Contact) request(chatID int64) *apiRequest {
+	return newRequest("sendContact").set("chat_id", chatID).set("phone_number", message.PhoneNumber).set("first_name", message.FirstName).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -190,7 +215,12 @@ type Dice struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Dice) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendDice(chatID, message.Emoji, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Dice) request(chatID int64) *apiRequest {
+	return newRequest("sendDice").set("chat_id", chatID).set("emoji", message.Emoji).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -230,7 +260,12 @@ type Document struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Document) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendDocument(message.File, chatID, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Document) request(chatID int64) *apiRequest {
+	return newRequest("sendDocument").set("chat_id", chatID).file("document", message.File).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -270,7 +305,12 @@ type Game struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Game) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendGame(message.GameShortName, chatID, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Game) request(chatID int64) *apiRequest {
+	return newRequest("sendGame").set("chat_id", chatID).set("game_short_name", message.GameShortName).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -310,7 +350,12 @@ type Location struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Location) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendLocation(chatID, message.Latitude, message.Longitude, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Location) request(chatID int64) *apiRequest {
+	return newRequest("sendLocation").set("chat_id", chatID).set("latitude", message.Latitude).set("longitude", message.Longitude).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -350,7 +395,12 @@ type Text struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Text) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendMessage(message.Text, chatID, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Text) request(chatID int64) *apiRequest {
+	return newRequest("sendMessage").set("chat_id", chatID).set("text", message.Text).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -390,7 +440,12 @@ type Photo struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Photo) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendPhoto(message.File, chatID, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Photo) request(chatID int64) *apiRequest {
+	return newRequest("sendPhoto").set("chat_id", chatID).file("photo", message.File).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -431,7 +486,12 @@ type Poll struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Poll) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendPoll(chatID, message.Question, message.Options, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Poll) request(chatID int64) *apiRequest {
+	return newRequest("sendPoll").set("chat_id", chatID).set("question", message.Question).set("options", message.Options).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -471,7 +531,12 @@ type Sticker struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Sticker) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendSticker(message.StickerID, chatID, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Sticker) request(chatID int64) *apiRequest {
+	return newRequest("sendSticker").set("chat_id", chatID).set("sticker", message.StickerID).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -512,7 +577,12 @@ type Venue struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Venue) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendVenue(chatID, message.Latitude, message.Longitude, message.Title, message.Address, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Venue) request(chatID int64) *apiRequest {
+	return newRequest("sendVenue").set("chat_id", chatID).set("latitude", message.Latitude).set("longitude", message.Longitude).set("title", message.Title).set("address", message.Address).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -552,7 +622,12 @@ type Video struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Video) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendVideo(message.File, chatID, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Video) request(chatID int64) *apiRequest {
+	return newRequest("sendVideo").set("chat_id", chatID).file("video", message.File).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -592,7 +667,12 @@ type VideoNote struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message VideoNote) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendVideoNote(message.File, chatID, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message VideoNote) request(chatID int64) *apiRequest {
+	return newRequest("sendVideoNote").set("chat_id", chatID).file("video_note", message.File).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -632,7 +712,12 @@ type Voice struct {
 
 // Send the message to the specified user (by this method the stuct can be used a Any interface)
 func (message Voice) Send(chatID int64) (res *UpdateMessage, err error) {
-	return clearResponse(api.SendVoice(message.File, chatID, message.Opts))
+	return defaultClient.Send(message, chatID)
+}
+
+// request returns the request to send the message to the specified chat (by this method the stuct can be sent by any Client)
+func (message Voice) request(chatID int64) *apiRequest {
+	return newRequest("sendVoice").set("chat_id", chatID).file("voice", message.File).with(message.Opts)
 }
 
 // ClipKeyboard allows to quickly add or change the Opts.ReplyMarkup of the current message
@@ -0,0 +1,179 @@
+package message
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// Rate is the maximum number of messages that can be sent in a period of time
+type Rate struct {
+	Count int           // Max number of messages, 0 means no limit
+	Per   time.Duration // Period of time, ex. time.Second
+}
+
+// RateLimits contains the limits respected by every Send to avoid hitting the
+// Telegram flood limits (and so the 429 Too Many Requests errors)
+type RateLimits struct {
+	Global     Rate // Limit of messages sent to all chats
+	PerChat    Rate // Limit of messages sent to the same chat
+	PerGroup   Rate // Further limit of messages sent to the same group or channel
+	MaxRetries int  // How many times a message is sent again after waiting the retry_after given by Telegram
+}
+
+// DefaultRateLimits are the rate limits suggested by Telegram, used by default
+var DefaultRateLimits = RateLimits{
+	Global:     Rate{30, time.Second},
+	PerChat:    Rate{1, time.Second},
+	PerGroup:   Rate{20, time.Minute},
+	MaxRetries: 3,
+}
+
+// SetRateLimits changes the rate limits of the outgoing messages of the default
+// client, use nil to disable them (even the retries after a 429 Too Many Requests error)
+func SetRateLimits(limits *RateLimits) {
+	defaultClient.SetRateLimits(limits)
+}
+
+// bucket keeps the theoretical arrival time of the next message of a Rate
+// (see Generic Cell Rate Algorithm), allowing bursts of Rate.Count messages
+type bucket struct {
+	tat time.Time
+}
+
+// reserve a slot for a message and return how much time to wait before sending it
+func (b *bucket) reserve(rate Rate, now time.Time) time.Duration {
+	if rate.Count <= 0 || rate.Per <= 0 {
+		return 0
+	}
+
+	var interval = rate.Per / time.Duration(rate.Count)
+	if b.tat.Before(now) {
+		b.tat = now
+	}
+	wait := b.tat.Add(interval - rate.Per).Sub(now)
+	b.tat = b.tat.Add(interval)
+
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// chatBuckets are the buckets of a single chat
+type chatBuckets struct {
+	chat, group bucket
+}
+
+// rateLimiter schedules the outgoing messages respecting the RateLimits
+type rateLimiter struct {
+	limits RateLimits
+	mu     sync.Mutex
+	global bucket
+	chats  map[int64]*chatBuckets
+
+	cleaned time.Time // last time the chats have been cleaned
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	return &rateLimiter{limits: limits, chats: make(map[int64]*chatBuckets)}
+}
+
+// reserve a slot for a message to the given chat and return how much time to
+// wait before sending it. Group and channels have a negative chat ID, while 0
+// is used by the requests not addressed to a chat, limited only by Global
+func (l *rateLimiter) reserve(chatID int64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var now = time.Now()
+	l.clean(now)
+
+	if chatID == 0 {
+		return l.global.reserve(l.limits.Global, now)
+	}
+
+	b := l.chats[chatID]
+	if b == nil {
+		b = new(chatBuckets)
+		l.chats[chatID] = b
+	}
+
+	wait := l.global.reserve(l.limits.Global, now)
+	if w := b.chat.reserve(l.limits.PerChat, now); w > wait {
+		wait = w
+	}
+	if chatID < 0 {
+		if w := b.group.reserve(l.limits.PerGroup, now); w > wait {
+			wait = w
+		}
+	}
+	return wait
+}
+
+// clean removes the chats whose limits are no longer relevant, at most once a minute. mu must be locked
+func (l *rateLimiter) clean(now time.Time) {
+	if now.Sub(l.cleaned) < time.Minute {
+		return
+	}
+	l.cleaned = now
+
+	// the builtin delete is shadowed in this package, so the active chats are copied
+	var active = make(map[int64]*chatBuckets)
+	for id, b := range l.chats {
+		if b.chat.tat.After(now) || b.group.tat.After(now) {
+			active[id] = b
+		}
+	}
+	l.chats = active
+}
+
+// limited performs the given call to the chat respecting the rate limits of the
+// client and retrying it when Telegram replies with 429 Too Many Requests
+func limited[T echotron.APIResponse](c *Client, chatID int64, call func() (T, error)) (res T, err error) {
+	var l = c.limiter
+	if l == nil {
+		return call()
+	}
+
+	for attempt := 0; ; attempt++ {
+		time.Sleep(l.reserve(chatID))
+
+		res, err = call()
+		wait, ok := retryAfter(err)
+		if !ok || attempt >= l.limits.MaxRetries {
+			return
+		}
+		time.Sleep(wait)
+	}
+}
+
+// retryAfterRgx matches the time to wait contained on the description of a 429 error
+var retryAfterRgx = regexp.MustCompile(`retry after (\d+)`)
+
+// retryAfter returns how long to wait before retrying if err is a 429 Too Many Requests error
+func retryAfter(err error) (time.Duration, bool) {
+	var (
+		apiErr      *echotron.APIError
+		resErr      *ResponseError
+		description string
+	)
+	switch {
+	case errors.As(err, &apiErr) && apiErr.ErrorCode() == 429:
+		description = apiErr.Description()
+	case errors.As(err, &resErr) && resErr.From == "Telegram" && resErr.ErrorCode == 429:
+		description = resErr.Description
+	default:
+		return 0, false
+	}
+
+	var seconds = 1
+	if match := retryAfterRgx.FindStringSubmatch(description); match != nil {
+		seconds, _ = strconv.Atoi(match[1])
+	}
+	return time.Duration(seconds) * time.Second, true
+}
@@ -0,0 +1,152 @@
+package message
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+func TestBucketReserve(t *testing.T) {
+	var (
+		b    bucket
+		rate = Rate{Count: 3, Per: 3 * time.Second}
+		now  = time.Now()
+	)
+
+	// A burst of Count messages is sent right away
+	for i := 0; i < rate.Count; i++ {
+		if wait := b.reserve(rate, now); wait != 0 {
+			t.Fatalf("message %d of the burst waits %v, want 0", i, wait)
+		}
+	}
+
+	// Then they are spaced by Per / Count
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		if wait := b.reserve(rate, now); wait != want {
+			t.Errorf("message %d after the burst waits %v, want %v", i, wait, want)
+		}
+	}
+
+	// Once the slots are free again, a new burst is allowed
+	later := now.Add(time.Minute)
+	for i := 0; i < rate.Count; i++ {
+		if wait := b.reserve(rate, later); wait != 0 {
+			t.Errorf("message %d of the second burst waits %v, want 0", i, wait)
+		}
+	}
+}
+
+func TestBucketReserveNoLimit(t *testing.T) {
+	var b bucket
+	for _, rate := range []Rate{{}, {Count: 1}, {Per: time.Second}} {
+		for i := 0; i < 5; i++ {
+			if wait := b.reserve(rate, time.Now()); wait != 0 {
+				t.Errorf("reserve(%+v) waits %v, want 0", rate, wait)
+			}
+		}
+	}
+}
+
+func TestRateLimiterReserve(t *testing.T) {
+	l := newRateLimiter(RateLimits{
+		PerChat:  Rate{1, time.Minute},
+		PerGroup: Rate{2, time.Hour},
+	})
+
+	if wait := l.reserve(42); wait != 0 {
+		t.Errorf("first message to a chat waits %v, want 0", wait)
+	}
+	if wait := l.reserve(42); wait < 59*time.Second {
+		t.Errorf("second message to a chat waits %v, want about a minute", wait)
+	}
+	if wait := l.reserve(7); wait != 0 {
+		t.Errorf("message to another chat waits %v, want 0", wait)
+	}
+	if wait := l.reserve(0); wait != 0 {
+		t.Errorf("request not addressed to a chat waits %v, want 0", wait)
+	}
+
+	// Groups are also limited by PerGroup
+	if wait := l.reserve(-100); wait != 0 {
+		t.Errorf("first message to a group waits %v, want 0", wait)
+	}
+	if wait := l.reserve(-100); wait < 59*time.Second || wait > time.Minute {
+		t.Errorf("second message to a group waits %v, want the PerChat minute", wait)
+	}
+	if wait := l.reserve(-100); wait < 29*time.Minute {
+		t.Errorf("third message to a group waits %v, want about half an hour", wait)
+	}
+}
+
+func TestRateLimiterGlobal(t *testing.T) {
+	l := newRateLimiter(RateLimits{Global: Rate{2, time.Minute}})
+
+	for _, chatID := range []int64{1, 2} {
+		if wait := l.reserve(chatID); wait != 0 {
+			t.Errorf("message to chat %d waits %v, want 0", chatID, wait)
+		}
+	}
+	if wait := l.reserve(0); wait < 29*time.Second {
+		t.Errorf("third request waits %v, want about 30s", wait)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		err  error
+		wait time.Duration
+		ok   bool
+	}{
+		{nil, 0, false},
+		{errors.New("Too Many Requests: retry after 5"), 0, false},
+		{&ResponseError{"Telegram", 429, "Too Many Requests: retry after 5"}, 5 * time.Second, true},
+		{&ResponseError{"Telegram", 429, "Too Many Requests"}, time.Second, true},
+		{&ResponseError{"Telegram", 400, "Bad Request: chat not found"}, 0, false},
+		{&ResponseError{"Echotron", 429, "retry after 5"}, 0, false},
+	}
+
+	for _, c := range cases {
+		if wait, ok := retryAfter(c.err); wait != c.wait || ok != c.ok {
+			t.Errorf("retryAfter(%v) = %v, %t, want %v, %t", c.err, wait, ok, c.wait, c.ok)
+		}
+	}
+}
+
+func TestLimitedRetry(t *testing.T) {
+	var (
+		c     = NewClient("123:TEST")
+		calls int
+	)
+	c.SetRateLimits(&RateLimits{MaxRetries: 2})
+	tooMany := func() (echotron.APIResponseBase, error) {
+		calls++
+		return echotron.APIResponseBase{}, &ResponseError{"Telegram", 429, "Too Many Requests: retry after 0"}
+	}
+
+	// The call is retried until it succeeds
+	_, err := limited(c, 42, func() (echotron.APIResponseBase, error) {
+		if calls < 2 {
+			return tooMany()
+		}
+		calls++
+		return echotron.APIResponseBase{Ok: true}, nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("limited returned %v after %d calls, want nil after 3", err, calls)
+	}
+
+	// Or until MaxRetries is reached
+	calls = 0
+	if _, err = limited(c, 42, tooMany); err == nil || calls != 3 {
+		t.Errorf("limited returned %v after %d calls, want the 429 after 3", err, calls)
+	}
+
+	// Without rate limits the call is not retried
+	calls = 0
+	c.SetRateLimits(nil)
+	if _, err = limited(c, 42, tooMany); err == nil || calls != 1 {
+		t.Errorf("limited without limits returned %v after %d calls, want the 429 after 1", err, calls)
+	}
+}
@@ -0,0 +1,18 @@
+package message_test
+
+import (
+	"time"
+
+	"github.com/DazFather/parrbot/message"
+)
+
+func ExampleSetRateLimits() {
+	// Allow bursts of messages on the same chat, but at most 30 per minute
+	limits := message.DefaultRateLimits
+	limits.PerChat = message.Rate{Count: 30, Per: time.Minute}
+	message.SetRateLimits(&limits)
+
+	// Disable the rate limits at all
+	message.SetRateLimits(nil)
+	// Output:
+}
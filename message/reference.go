@@ -10,6 +10,7 @@ import (
 type Reference struct {
 	messageID int
 	chatID    int64
+	client    *Client
 	echotron.MessageIDOptions
 }
 
@@ -27,6 +28,7 @@ func NewReference(e editable) *Reference {
 	return &Reference{
 		messageID:        message.ID,
 		chatID:           message.Chat.ID,
+		client:           clientOf(e),
 		MessageIDOptions: *msgID,
 	}
 }
@@ -71,6 +73,6 @@ func (ref Reference) EditCaption(opts *echotron.MessageCaptionOptions) error {
 
 // Delete the message that is being referenced
 func (ref Reference) Delete() error {
-	_, err := api.DeleteMessage(ref.chatID, ref.messageID)
-	return err
+	var res echotron.APIResponseBool
+	return ref.client.orDefault().do(newRequest("deleteMessage").set("chat_id", ref.chatID).set("message_id", ref.messageID), &res)
 }
@@ -0,0 +1,247 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// apiRequest is a request to a method of the Bot API, made by a Client with its
+// own transport. It's encoded as echotron would do, so that the messages and
+// the options of echotron can still be used
+type apiRequest struct {
+	method string
+	params url.Values
+	files  []upload
+	err    error
+}
+
+// upload is a file sent in a multipart request
+type upload struct {
+	field, name string
+	content     []byte
+}
+
+// newRequest creates a request to the given method of the Bot API
+func newRequest(method string) *apiRequest {
+	return &apiRequest{method: method, params: make(url.Values)}
+}
+
+// set adds a parameter to the request, encoded as echotron does
+func (r *apiRequest) set(key string, value interface{}) *apiRequest {
+	r.params.Set(key, toParam(reflect.ValueOf(value)))
+	return r
+}
+
+// with adds to the request the non-zero fields of the options with a query
+// tag, as echotron does, and uploads the files among them (ex. Thumb)
+func (r *apiRequest) with(opts interface{}) *apiRequest {
+	v := reflect.ValueOf(opts)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return r
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		switch {
+		case field.Type == reflect.TypeOf(echotron.InputFile{}):
+			r.file(strings.ToLower(field.Name), v.Field(i).Interface().(echotron.InputFile))
+		case field.Tag.Get("query") != "" && !v.Field(i).IsZero():
+			r.params.Set(field.Tag.Get("query"), toParam(v.Field(i)))
+		}
+	}
+	return r
+}
+
+// file adds the file to the request: by its ID or URL as a parameter, by its
+// content (read from its path if needed) as an upload. Empty files are ignored
+func (r *apiRequest) file(field string, file echotron.InputFile) *apiRequest {
+	if id := inputFileField(file, "id").String(); id != "" {
+		r.params.Set(field, id)
+	} else if name, content, ok := r.readFile(file); ok {
+		r.files = append(r.files, upload{field, name, content})
+	}
+	return r
+}
+
+// media adds the media to the request as echotron does: in the "media"
+// parameter as json, with its file and thumb attached if they have to be uploaded
+func (r *apiRequest) media(media echotron.InputMedia) *apiRequest {
+	v := reflect.ValueOf(media)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		r.err = &ResponseError{"Parrbot", 1, "Invalid media"}
+		return r
+	}
+
+	envelope := map[string]interface{}{}
+	if data, err := json.Marshal(media); err != nil {
+		r.err = &ResponseError{"Parrbot", 1, err.Error()}
+	} else if err = json.Unmarshal(data, &envelope); err != nil {
+		r.err = &ResponseError{"Parrbot", 1, err.Error()}
+	}
+
+	for key, name := range map[string]string{"media": "Media", "thumb": "Thumb"} {
+		field := v.FieldByName(name)
+		if !field.IsValid() {
+			continue
+		}
+		file := field.Interface().(echotron.InputFile)
+		if id := inputFileField(file, "id").String(); id != "" {
+			envelope[key] = id
+		} else if name, content, ok := r.readFile(file); ok {
+			r.files = append(r.files, upload{name, name, content})
+			envelope[key] = "attach://" + name
+		}
+	}
+
+	return r.set("media", envelope)
+}
+
+// readFile returns the name and the content of the file to upload, reading
+// it from its path if needed. ok is false if there is nothing to upload
+func (r *apiRequest) readFile(file echotron.InputFile) (name string, content []byte, ok bool) {
+	name, content = inputFileField(file, "path").String(), inputFileField(file, "content").Bytes()
+	if name == "" {
+		return
+	}
+
+	if len(content) == 0 {
+		var err error
+		if content, err = os.ReadFile(name); err != nil {
+			r.err = &ResponseError{"Parrbot", 1, err.Error()}
+			return
+		}
+	}
+	return filepath.Base(name), content, true
+}
+
+// inputFileField returns the given unexported field of the file, as echotron
+// doesn't give any other way to read them
+func inputFileField(file echotron.InputFile, name string) reflect.Value {
+	return reflect.ValueOf(file).FieldByName(name)
+}
+
+// toParam encodes the value of a parameter as echotron does
+func toParam(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Struct, reflect.Interface, reflect.Slice, reflect.Array, reflect.Map:
+		data, _ := json.Marshal(v.Interface())
+		return string(data)
+	default:
+		return ""
+	}
+}
+
+// body returns the body of the request with its content type: a multipart
+// form if there are files to upload, an urlencoded form otherwise
+func (r *apiRequest) body() (io.Reader, string, error) {
+	if len(r.files) == 0 {
+		return strings.NewReader(r.params.Encode()), "application/x-www-form-urlencoded", nil
+	}
+
+	var (
+		buf = new(bytes.Buffer)
+		w   = multipart.NewWriter(buf)
+	)
+	for key, values := range r.params {
+		for _, value := range values {
+			if err := w.WriteField(key, value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	for _, f := range r.files {
+		part, err := w.CreateFormFile(f.field, f.name)
+		if err != nil {
+			return nil, "", err
+		}
+		part.Write(f.content)
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf, w.FormDataContentType(), nil
+}
+
+// post makes the request and decodes the response into res
+func (c *Client) post(ctx context.Context, r *apiRequest, res interface {
+	Base() echotron.APIResponseBase
+}) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	body, contentType, err := r.body()
+	if err != nil {
+		return &ResponseError{"Parrbot", 1, err.Error()}
+	}
+
+	var endpoint = "https://api.telegram.org/bot" + c.token + "/" + r.method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return &ResponseError{"Parrbot", 1, err.Error()}
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	response, err := c.httpClient().Do(req)
+	if err != nil {
+		return &ResponseError{"Parrbot", 1, err.Error()}
+	}
+	defer response.Body.Close()
+
+	if err = json.NewDecoder(response.Body).Decode(res); err != nil {
+		return &ResponseError{"Parrbot", 1, "Unable to decode the response of " + r.method + ": " + err.Error()}
+	}
+	if base := res.Base(); !base.Ok {
+		return &ResponseError{"Telegram", base.ErrorCode, base.Description}
+	}
+	return nil
+}
+
+// do makes the request, without any rate limit, and passes its outcome to the observer
+func (c *Client) do(r *apiRequest, res interface {
+	Base() echotron.APIResponseBase
+}) error {
+	start := time.Now()
+	return c.observe(r.method, start, c.post(context.Background(), r, res))
+}
+
+// download returns the content of the file at the given path on the Telegram servers
+func (c *Client) download(filePath string) ([]byte, error) {
+	response, err := c.httpClient().Get("https://api.telegram.org/file/bot" + c.token + "/" + filePath)
+	if err != nil {
+		return nil, &ResponseError{"Parrbot", 1, err.Error()}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, &ResponseError{"Telegram", response.StatusCode, "Unable to download " + filePath + ": " + response.Status}
+	}
+	return io.ReadAll(response.Body)
+}
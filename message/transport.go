@@ -0,0 +1,32 @@
+package message
+
+import "net/http"
+
+// SetTransport changes how the requests of the client to the Bot API are made,
+// ex. to record them or to answer them with a fake Bot API (see parrbottest).
+// Use nil to restore http.DefaultTransport. The echotron.API returned by API
+// doesn't use it, as echotron always makes its requests with http.DefaultTransport
+func (c *Client) SetTransport(transport http.RoundTripper) {
+	c = c.orDefault()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.transport = transport
+}
+
+// Transport returns the transport set with SetTransport, nil if the client
+// uses http.DefaultTransport
+func (c *Client) Transport() http.RoundTripper {
+	c = c.orDefault()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.transport
+}
+
+// httpClient returns the http.Client used to make the requests of the client
+func (c *Client) httpClient() *http.Client {
+	return &http.Client{Transport: c.Transport()}
+}
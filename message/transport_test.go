@@ -0,0 +1,72 @@
+package message_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DazFather/parrbot/message"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// transportFunc is an http.RoundTripper made by a function
+type transportFunc func(req *http.Request) (*http.Response, error)
+
+func (fn transportFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestSetTransport(t *testing.T) {
+	if _, ok := http.DefaultTransport.(*http.Transport); !ok {
+		t.Fatalf("http.DefaultTransport = %T, want *http.Transport", http.DefaultTransport)
+	}
+
+	var (
+		client = message.NewClient("1:TEST")
+		req    *http.Request
+		upload []byte
+	)
+	client.SetRateLimits(nil)
+	client.SetTransport(transportFunc(func(r *http.Request) (*http.Response, error) {
+		req = r
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			return nil, err
+		}
+		if file, _, err := r.FormFile("document"); err == nil {
+			upload, _ = io.ReadAll(file)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"ok":true,"result":{"message_id":1,"chat":{"id":42}}}`)),
+		}, nil
+	}))
+
+	_, err := client.Send(message.Document{
+		File: echotron.NewInputFileBytes("notes.txt", []byte("hello")),
+		Opts: &echotron.DocumentOptions{Caption: "notes"},
+	}, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req == nil || req.URL.Path != "/bot1:TEST/sendDocument" {
+		t.Fatalf("request = %v, want sendDocument made through the transport of the client", req)
+	}
+	if chatID, caption := req.FormValue("chat_id"), req.FormValue("caption"); chatID != "42" || caption != "notes" {
+		t.Errorf("params = chat_id %q, caption %q, want 42 and notes", chatID, caption)
+	}
+	if string(upload) != "hello" {
+		t.Errorf("uploaded document = %q, want \"hello\"", upload)
+	}
+
+	if message.NewClient("2:TEST").Transport() != nil {
+		t.Error("the transport of a client is used by another one")
+	}
+	client.SetTransport(nil)
+	if client.Transport() != nil {
+		t.Error("SetTransport(nil) doesn't restore the default transport")
+	}
+}
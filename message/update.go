@@ -2,7 +2,7 @@ package message
 
 import (
 	"encoding/json"
-	"log"
+	"strings"
 
 	"github.com/NicoNex/echotron/v3"
 )
@@ -26,6 +26,12 @@ type Update struct {
 	MyChatMember       *echotron.ChatMemberUpdated  `json:"my_chat_member,omitempty"`
 	ChatMember         *echotron.ChatMemberUpdated  `json:"chat_member,omitempty"`
 	ChatJoinRequest    *echotron.ChatJoinRequest    `json:"chat_join_request,omitempty"`
+
+	// Params contains the values extracted by the Pattern of the robot.Command
+	// that has been triggered by this update (if any)
+	Params map[string]string `json:"parrbot_params,omitempty"`
+
+	client *Client // client that received the update
 }
 
 // UpdateType represent a possible incoming Update types used on the "ReplyAt" Command inside the command list
@@ -54,6 +60,37 @@ const (
 	ANY = (1 << iota) - 1 // 1111111111
 )
 
+// ChatType represent the type of chat where an update has been generated. It's
+// used as flag on the "Chats" field of a robot.Command and, as for UpdateType,
+// you can sum them to include more types. If you want all, you can use ANY_CHAT
+type ChatType uint8
+
+// These are all the possible types of chat. On the side the binary representation
+const (
+	PRIVATE_CHAT    ChatType = 1 << iota // 0001
+	GROUP_CHAT                           // 0010
+	SUPERGROUP_CHAT                      // 0100
+	CHANNEL_CHAT                         // 1000
+
+	// ANY_CHAT represents any possible ChatType
+	ANY_CHAT = (1 << iota) - 1 // 1111
+)
+
+// castChatType transform the Telegram's type of chat into a ChatType (0 if unknown)
+func castChatType(chatType string) ChatType {
+	switch chatType {
+	case "private", "sender":
+		return PRIVATE_CHAT
+	case "group":
+		return GROUP_CHAT
+	case "supergroup":
+		return SUPERGROUP_CHAT
+	case "channel":
+		return CHANNEL_CHAT
+	}
+	return 0
+}
+
 // ForwardInfo countain all the infos of the original message that has been forwarded
 type ForwardInfo struct {
 	From       *echotron.User `json:"forward_from,omitempty"`
@@ -88,8 +125,9 @@ type SystemNotificationInfo struct {
 	PinnedMessage                 *UpdateMessage                          `json:"parrbot_pinned_message,omitempty"`
 }
 
-// castCallbackQuery transform an *echotron.CallbackQuery into a *CallbackQuery
-func castCallbackQuery(original *echotron.CallbackQuery) (callback *CallbackQuery) {
+// castCallbackQuery transform an *echotron.CallbackQuery into a *CallbackQuery bound
+// to the given client. If the callback can't be casted the error is logged and nil is returned
+func castCallbackQuery(c *Client, original *echotron.CallbackQuery) (callback *CallbackQuery) {
 	if original == nil { // Guard close
 		return nil
 	}
@@ -97,25 +135,33 @@ func castCallbackQuery(original *echotron.CallbackQuery) (callback *CallbackQuer
 	// Get JSON format of the original
 	var jsonData, err = json.Marshal(*original)
 	if err != nil {
-		log.Fatal(err)
+		c.Logger().Error("Unable to cast callback query", "callback_id", original.ID, "error", err)
+		return nil
 	}
 
 	// Copy common values to the new callback
-	callback = new(CallbackQuery)
+	callback = &CallbackQuery{client: c}
 	if err = json.Unmarshal(jsonData, callback); err != nil {
-		log.Fatal(err)
+		c.Logger().Error("Unable to cast callback query", "callback_id", original.ID, "error", err)
+		return nil
 	}
 
 	// Cast *echotron.Message into *UpdateMessage
 	if original.Message != nil {
-		callback.Message = castMessage(original.Message)
+		callback.Message = castMessage(c, original.Message)
 	}
 
 	return
 }
 
-// CastUpdate transform an *echotron.Update into a *Update
-func CastUpdate(original *echotron.Update) (update *Update) {
+// CastUpdate transform an *echotron.Update into a *Update. If the update
+// can't be casted the error is logged and nil is returned
+func CastUpdate(original *echotron.Update) *Update {
+	return castUpdate(defaultClient, original)
+}
+
+// castUpdate transform an *echotron.Update into a *Update bound to the given client
+func castUpdate(c *Client, original *echotron.Update) (update *Update) {
 	if original == nil { // Guard close
 		return nil
 	}
@@ -123,32 +169,109 @@ func CastUpdate(original *echotron.Update) (update *Update) {
 	// Get JSON format of the original echotron.Update
 	var jsonData, err = json.Marshal(*original)
 	if err != nil {
-		log.Fatal(err)
+		c.Logger().Error("Unable to cast update", "update_id", original.ID, "error", err)
+		return nil
 	}
 
 	// Copy common values to the new update
-	update = new(Update)
+	update = &Update{client: c}
 	if err = json.Unmarshal(jsonData, update); err != nil {
-		log.Fatal(err)
+		c.Logger().Error("Unable to cast update", "update_id", original.ID, "error", err)
+		return nil
 	}
 
 	// Cast *echotron.Message into *UpdateMessage
-	update.Message = castMessage(original.Message)
-	update.EditedMessage = castMessage(original.EditedMessage)
-	update.ChannelPost = castMessage(original.ChannelPost)
-	update.EditedChannelPost = castMessage(original.EditedChannelPost)
+	update.Message = castMessage(c, original.Message)
+	update.EditedMessage = castMessage(c, original.EditedMessage)
+	update.ChannelPost = castMessage(c, original.ChannelPost)
+	update.EditedChannelPost = castMessage(c, original.EditedChannelPost)
 
 	// Cast *echotron.CallbackQuery into *CallbackQuery
-	update.CallbackQuery = castCallbackQuery(original.CallbackQuery)
+	update.CallbackQuery = castCallbackQuery(c, original.CallbackQuery)
 
 	return
 }
 
+// Client returns the client that received the update, useful to send messages
+// on behalf of the same bot when running more than one
+func (u Update) Client() *Client {
+	return u.client.orDefault()
+}
+
 // FromMessage gets the original message contain in the update if present
 func (u Update) FromMessage() (msg *UpdateMessage) {
 	return u.grabMessage()
 }
 
+// ChatType returns the type of the chat where the update has been generated,
+// or 0 if it's not possible to know it (ex. chosen inline results)
+func (u Update) ChatType() ChatType {
+	switch {
+	case u.InlineQuery != nil:
+		return castChatType(u.InlineQuery.ChatType)
+	case u.MyChatMember != nil:
+		return castChatType(u.MyChatMember.Chat.Type)
+	case u.ChatMember != nil:
+		return castChatType(u.ChatMember.Chat.Type)
+	case u.ChatJoinRequest != nil:
+		return castChatType(u.ChatJoinRequest.Chat.Type)
+	}
+
+	if msg := u.grabMessage(); msg != nil && msg.Chat != nil {
+		return castChatType(msg.Chat.Type)
+	}
+	return 0
+}
+
+// Sender returns the user that generated the update, or nil if missing (ex.
+// channel posts or messages sent on behalf of a chat)
+func (u Update) Sender() *echotron.User {
+	switch {
+	case u.InlineQuery != nil:
+		return u.InlineQuery.From
+	case u.ChosenInlineResult != nil:
+		return u.ChosenInlineResult.From
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.From
+	case u.ShippingQuery != nil:
+		return &u.ShippingQuery.From
+	case u.PreCheckoutQuery != nil:
+		return &u.PreCheckoutQuery.From
+	case u.MyChatMember != nil:
+		return &u.MyChatMember.From
+	case u.ChatMember != nil:
+		return &u.ChatMember.From
+	case u.ChatJoinRequest != nil:
+		return &u.ChatJoinRequest.From
+	}
+
+	if msg := u.grabMessage(); msg != nil {
+		return msg.From
+	}
+	return nil
+}
+
+// Param returns the value of the given param extracted by the pattern of the
+// command, or an empty string if missing
+func (u Update) Param(name string) string {
+	return u.Params[name]
+}
+
+// StartPayload returns the payload of the deep link that started the bot, the
+// text after "/start" (ex. "ref_123" for "t.me/MyBot?start=ref_123"), or an
+// empty string if the update is not a message carrying the "/start" command
+func (u Update) StartPayload() string {
+	if u.Message == nil {
+		return ""
+	}
+
+	command, payload, _ := strings.Cut(strings.TrimSpace(u.Message.Text), " ")
+	if command, _, _ = strings.Cut(command, "@"); command != "/start" {
+		return ""
+	}
+	return strings.TrimSpace(payload)
+}
+
 // Deletes the original message contain in the update if present
 func (u Update) DeleteMessage() error {
 	return delete(u)
@@ -0,0 +1,189 @@
+package parrbottest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DazFather/parrbot/robot"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// StartTimeout is how long Start waits for the robot to be ready
+var StartTimeout = 5 * time.Second
+
+// Driver runs a Robot against a fake Bot API, allowing to send it messages and
+// callback queries as a user would and then check the calls it made
+type Driver struct {
+	*Server
+	Robot *robot.Robot
+
+	mu     sync.Mutex
+	lastID int // ID of the last callback query
+	cancel context.CancelFunc
+	done   chan error // result of Robot.Run
+}
+
+// Start creates a Server and runs on it a new Robot with the given configuration
+// and commands, waiting for it to be ready. The token is replaced by the one of
+// the server, the webhook is ignored and the rate limits are disabled. Use Close
+// to stop it
+func Start(config robot.ParrbotConfig, commandList ...robot.Command) (*Driver, error) {
+	var server = NewServer()
+
+	config.Webhook = nil
+	r, err := server.NewRobot(config)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Driver{
+		Server: server,
+		Robot:  r,
+		cancel: cancel,
+		done:   make(chan error, 1),
+	}
+	go func() {
+		d.done <- d.Robot.Run(ctx, commandList...)
+	}()
+
+	// Polling starts deleting the webhook, when the robot is ready to get updates
+	ready := make(chan bool, 1)
+	go func() {
+		_, ok := d.WaitFor("deleteWebhook", StartTimeout)
+		ready <- ok
+	}()
+
+	select {
+	case err := <-d.done:
+		d.Server.Close()
+		d.Robot.Client().SetTransport(nil)
+		cancel()
+		if err == nil {
+			err = errors.New("Robot stopped before being ready")
+		}
+		return nil, err
+	case ok := <-ready:
+		if !ok {
+			d.Close()
+			return nil, errors.New("Robot not ready before StartTimeout")
+		}
+	}
+
+	d.Reset()
+	return d, nil
+}
+
+// NewRobot creates a Robot with the given configuration that uses the server:
+// the token is replaced by the one of the server and the rate limits are
+// disabled. Unlike Start, the robot is not run
+func (s *Server) NewRobot(config robot.ParrbotConfig) (*robot.Robot, error) {
+	if err := config.SetAPIToken(s.Token); err != nil {
+		return nil, err
+	}
+
+	r := robot.New(config)
+	r.Client().SetTransport(s)
+	r.Client().SetRateLimits(nil)
+	return r, nil
+}
+
+// Replay starts a Driver and passes to its robot the updates recorded on the
+// file at the given path (see robot.Recorder), returning the driver to check
+// the calls made. Use Close to stop it
+func Replay(path string, config robot.ParrbotConfig, commandList ...robot.Command) (*Driver, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	d, err := Start(config, commandList...)
+	if err != nil {
+		return nil, err
+	}
+	if err = d.Robot.Replay(file); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// Close stops the robot, waiting for its shutdown, and the server
+func (d *Driver) Close() error {
+	d.cancel()
+	err := <-d.done
+	d.Server.Close()
+	d.Robot.Client().SetTransport(nil)
+	return err
+}
+
+// Update passes the update to the robot, waiting for its handler to return
+func (d *Driver) Update(update *echotron.Update) error {
+	return d.Robot.Serve(update)
+}
+
+// SendMessage sends a text message to the robot from the user with the same ID
+// of the given chat (or from a generic user on groups), returning the message
+// after the handler returned
+func (d *Driver) SendMessage(chatID int64, text string) (echotron.Message, error) {
+	msg := d.Store(echotron.Message{
+		From: user(chatID),
+		Chat: echotron.Chat{ID: chatID, Type: chatType(chatID)},
+		Text: text,
+	})
+	return msg, d.Update(&echotron.Update{Message: &msg})
+}
+
+// Click presses the inline button of the given message (previously sent by the
+// robot) that has the given callback data, ex. the one given by tgui.InlineCaller
+func (d *Driver) Click(msg echotron.Message, data string) error {
+	current, ok := d.Message(msg.Chat.ID, msg.ID)
+	if !ok {
+		return errors.New("Message " + strconv.Itoa(msg.ID) + " not found")
+	}
+	msg = current
+	if !hasButton(msg, data) {
+		return errors.New("Message " + strconv.Itoa(msg.ID) + " has no button with data " + strconv.Quote(data))
+	}
+
+	d.mu.Lock()
+	d.lastID++
+	id := strconv.Itoa(d.lastID)
+	d.mu.Unlock()
+
+	return d.Update(&echotron.Update{CallbackQuery: &echotron.CallbackQuery{
+		ID:      id,
+		From:    user(msg.Chat.ID),
+		Message: &msg,
+		Data:    data,
+	}})
+}
+
+// user returns the user that sends the updates on the given chat
+func user(chatID int64) *echotron.User {
+	if chatID < 0 {
+		return &echotron.User{ID: 42, FirstName: "Tester"}
+	}
+	return &echotron.User{ID: chatID, FirstName: "Tester"}
+}
+
+// hasButton returns true if the message has an inline button with the given data
+func hasButton(msg echotron.Message, data string) bool {
+	if msg.ReplyMarkup == nil {
+		return false
+	}
+	for _, row := range msg.ReplyMarkup.InlineKeyboard {
+		for _, button := range row {
+			if button.CallbackData == data {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package parrbottest_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+	"github.com/DazFather/parrbot/tgui"
+)
+
+func ExampleStart() {
+	counter := func(bot *robot.Bot, update *message.Update) message.Any {
+		var count int
+		bot.GetData("count", &count)
+		bot.SetData("count", count+1)
+
+		kbd := tgui.InlineKbdOpt(nil, tgui.Wrap(tgui.Wrap(tgui.InlineCaller("➕", "/count"))))
+		tgui.ShowMessage(*update, fmt.Sprint("Count: ", count+1), kbd)
+		return nil
+	}
+
+	d, err := parrbottest.Start(robot.DefaultConfig(), robot.Command{
+		Trigger:  "/count",
+		ReplyAt:  message.MESSAGE + message.CALLBACK_QUERY,
+		CallFunc: counter,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer d.Close()
+
+	d.SendMessage(42, "/count")
+	sent, _ := d.LastMessage(42)
+	fmt.Println(sent.Text)
+
+	d.Click(sent, "/count")
+	edited, _ := d.Message(42, sent.ID)
+	fmt.Println(edited.Text)
+
+	for _, call := range d.Calls() {
+		fmt.Println(call.Method, call.Text())
+	}
+	// Output:
+	// Count: 1
+	// Count: 2
+	// sendMessage Count: 1
+	// editMessageText Count: 2
+	// answerCallbackQuery
+}
+
+func ExampleReplay() {
+	echo := robot.Command{
+		Trigger: "/echo",
+		ReplyAt: message.MESSAGE,
+		CallFunc: func(bot *robot.Bot, update *message.Update) message.Any {
+			return message.Text{Text: strings.TrimPrefix(update.Message.Text, "/echo ")}
+		},
+	}
+	path := filepath.Join(os.TempDir(), "parrbot-recording.jsonl")
+	defer os.Remove(path)
+
+	// Record a conversation
+	config := robot.DefaultConfig()
+	config.Recorder, _ = robot.CreateRecording(path)
+	d, err := parrbottest.Start(config, echo)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	d.SendMessage(42, "/echo Hello")
+	d.SendMessage(42, "/echo World")
+	d.Close()
+	config.Recorder.Close()
+
+	// Replay it without recording
+	if d, err = parrbottest.Replay(path, robot.DefaultConfig(), echo); err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer d.Close()
+
+	for _, call := range d.CallsTo("sendMessage") {
+		fmt.Println(call.Text())
+	}
+	// Output:
+	// Hello
+	// World
+}
+
+func TestStartParallel(t *testing.T) {
+	for _, name := range []string{"first", "second", "third"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			d, err := parrbottest.Start(robot.DefaultConfig(), robot.Command{
+				Trigger: "/name",
+				ReplyAt: message.MESSAGE,
+				CallFunc: func(*robot.Bot, *message.Update) message.Any {
+					return message.Text{Text: name}
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			for i := 0; i < 10; i++ {
+				if _, err = d.SendMessage(42, "/name"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			calls := d.CallsTo("sendMessage")
+			if len(calls) != 10 {
+				t.Fatalf("server received %d messages, want 10", len(calls))
+			}
+			for _, call := range calls {
+				if call.Text() != name || call.Token != d.Token {
+					t.Errorf("server received %q with token %s, want %q with %s", call.Text(), call.Token, name, d.Token)
+				}
+			}
+		})
+	}
+}
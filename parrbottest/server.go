@@ -0,0 +1,499 @@
+package parrbottest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// Call is a request received by the fake Bot API
+type Call struct {
+	Method string     // Name of the method of the Bot API, ex. "sendMessage"
+	Token  string     // Token of the bot that made the request
+	Params url.Values // Parameters of the request
+}
+
+// ChatID returns the "chat_id" parameter of the call, 0 if missing
+func (c Call) ChatID() int64 {
+	id, _ := strconv.ParseInt(c.Params.Get("chat_id"), 10, 64)
+	return id
+}
+
+// MessageID returns the "message_id" parameter of the call, 0 if missing
+func (c Call) MessageID() int {
+	id, _ := strconv.Atoi(c.Params.Get("message_id"))
+	return id
+}
+
+// Text returns the "text" parameter of the call, or the "caption" one if missing
+func (c Call) Text() string {
+	if text := c.Params.Get("text"); text != "" {
+		return text
+	}
+	return c.Params.Get("caption")
+}
+
+// Decode unmarshals the JSON encoded parameter with the given key into dst
+func (c Call) Decode(key string, dst interface{}) error {
+	return json.Unmarshal([]byte(c.Params.Get(key)), dst)
+}
+
+// InlineKeyboard returns the inline keyboard attached by the call, nil if missing
+func (c Call) InlineKeyboard() [][]echotron.InlineKeyboardButton {
+	var markup echotron.InlineKeyboardMarkup
+	c.Decode("reply_markup", &markup)
+	return markup.InlineKeyboard
+}
+
+// Keyboard returns the reply keyboard attached by the call, nil if missing
+func (c Call) Keyboard() [][]echotron.KeyboardButton {
+	var markup echotron.ReplyKeyboardMarkup
+	c.Decode("reply_markup", &markup)
+	return markup.Keyboard
+}
+
+// Error is an unsuccessful response of the fake Bot API, ex.
+// &Error{403, "Forbidden: bot was blocked by the user"}
+type Error struct {
+	Code        int
+	Description string
+}
+
+// Error returns the description of the error
+func (e *Error) Error() string {
+	return e.Description
+}
+
+// HandlerFunc generates the result of a call. Returning an error, the call
+// will fail with the code of the *Error or 400 (Bad Request) for other errors
+type HandlerFunc func(call Call) (result interface{}, err error)
+
+// Server is a fake Telegram Bot API that records the received calls and answers
+// them with plausible results: sent messages get a new ID, edits and deletions
+// are applied to the stored messages and other methods succeed. Use Handle to
+// customize the response of a method
+type Server struct {
+	Me    echotron.User // User of the bot, returned by getMe
+	Token string        // Fake token of the bot, different for each server
+
+	mu       sync.Mutex
+	calls    []Call
+	handlers map[string]HandlerFunc
+	messages map[int64]map[int]*echotron.Message // messages by chat and ID
+	lastID   int                                 // ID of the last stored message
+	files    map[string]echotron.File            // stored files by ID
+	contents map[string][]byte                   // content of the stored files by path
+	updateID int                                 // ID of the last pushed update
+	updates  []*echotron.Update                  // updates waiting for getUpdates
+	notify   chan struct{}                       // closed and replaced at every change
+	closed   chan struct{}
+}
+
+// servers is the number of servers created, used to give each one its own token
+var servers atomic.Int64
+
+// NewServer creates a fake Bot API. To make a bot use it, create its client
+// with the Token of the server and set the server as its transport, ex:
+//
+//	config.SetAPIToken(server.Token)
+//	robot.New(config).Client().SetTransport(server)
+//
+// The transport is set only on that client, so tests using different servers can run in parallel
+func NewServer() *Server {
+	s := &Server{
+		Me:       echotron.User{ID: 1, IsBot: true, FirstName: "Parr(B)ot", Username: "parrbot"},
+		Token:    strconv.FormatInt(123456+servers.Add(1), 10) + ":TEST",
+		handlers: make(map[string]HandlerFunc),
+		messages: make(map[int64]map[int]*echotron.Message),
+		files:    make(map[string]echotron.File),
+		contents: make(map[string][]byte),
+		notify:   make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+	return s
+}
+
+// Close stops the pending getUpdates calls
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.closed:
+		return
+	default:
+		close(s.closed)
+	}
+}
+
+// Handle sets the function that generates the result of the calls to the given
+// method, replacing the default behavior. Use nil to restore it
+func (s *Server) Handle(method string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fn == nil {
+		delete(s.handlers, method)
+	} else {
+		s.handlers[method] = fn
+	}
+}
+
+// Calls returns all the calls received since the creation or the last Reset,
+// except the ones to getUpdates
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo returns the received calls to the given method, ex. "sendMessage"
+func (s *Server) CallsTo(method string) (calls []Call) {
+	for _, call := range s.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return
+}
+
+// Last returns the last received call, false if there are none
+func (s *Server) Last() (Call, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.calls) == 0 {
+		return Call{}, false
+	}
+	return s.calls[len(s.calls)-1], true
+}
+
+// WaitFor waits until a call to the given method has been received, returning
+// the last one, or false when timeout expires. Useful when the call is made by
+// a goroutine started by the handler or by a scheduled job
+func (s *Server) WaitFor(method string, timeout time.Duration) (Call, bool) {
+	expired := time.After(timeout)
+	for {
+		s.mu.Lock()
+		notify := s.notify
+		for i := len(s.calls) - 1; i >= 0; i-- {
+			if s.calls[i].Method == method {
+				defer s.mu.Unlock()
+				return s.calls[i], true
+			}
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-expired:
+			return Call{}, false
+		}
+	}
+}
+
+// Reset forgets the received calls
+func (s *Server) Reset() {
+	s.mu.Lock()
+	s.calls = nil
+	s.mu.Unlock()
+}
+
+// Message returns the current state of a message sent by the bot (or given to
+// Store), false if it doesn't exist or has been deleted
+func (s *Server) Message(chatID int64, messageID int) (echotron.Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if msg, ok := s.messages[chatID][messageID]; ok {
+		return *msg, true
+	}
+	return echotron.Message{}, false
+}
+
+// LastMessage returns the current state of the last message sent by the bot on
+// the given chat that has not been deleted, false if there are none
+func (s *Server) LastMessage(chatID int64) (last echotron.Message, found bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, msg := range s.messages[chatID] {
+		if msg.From != nil && msg.From.ID == s.Me.ID && msg.ID > last.ID {
+			last, found = *msg, true
+		}
+	}
+	return
+}
+
+// Store saves a message (ex. sent by the user) so that it can be edited,
+// deleted or forwarded by the bot. If the ID is 0 a new one will be assigned
+func (s *Server) Store(msg echotron.Message) echotron.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.store(&msg)
+}
+
+// store saves the message, mu must be locked
+func (s *Server) store(msg *echotron.Message) *echotron.Message {
+	if msg.ID == 0 {
+		s.lastID++
+		msg.ID = s.lastID
+	} else if msg.ID > s.lastID {
+		s.lastID = msg.ID
+	}
+	if msg.Date == 0 {
+		msg.Date = int(time.Now().Unix())
+	}
+
+	chatID := msg.Chat.ID
+	if s.messages[chatID] == nil {
+		s.messages[chatID] = make(map[int]*echotron.Message)
+	}
+	s.messages[chatID][msg.ID] = msg
+	return msg
+}
+
+// Push queues an update that will be returned to the bot by getUpdates
+func (s *Server) Push(update *echotron.Update) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateID++
+	update.ID = s.updateID
+	s.updates = append(s.updates, update)
+	s.changed()
+}
+
+// changed wakes up who is waiting for a change, mu must be locked
+func (s *Server) changed() {
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// StoreFile saves a file (ex. sent by the user) at the given path, so that the
+// bot can download it. Use the FileID of the returned file on the messages
+func (s *Server) StoreFile(filePath string, content []byte) echotron.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file := echotron.File{
+		FileID:       "file_" + strconv.Itoa(len(s.files)+1),
+		FileUniqueID: "unique_" + strconv.Itoa(len(s.files)+1),
+		FileSize:     int64(len(content)),
+		FilePath:     filePath,
+	}
+	s.files[file.FileID] = file
+	s.contents[filePath] = content
+	return file
+}
+
+// ServeHTTP answers the requests to the Bot API, whose path are in the format
+// /bot<TOKEN>/<METHOD>, and the downloads of the stored files, whose path are
+// in the format /file/bot<TOKEN>/<FILE_PATH>
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if strings.HasPrefix(path, "file/bot") {
+		s.download(w, r, strings.TrimPrefix(path, "file/bot"))
+		return
+	}
+	if !strings.HasPrefix(path, "bot") || !strings.Contains(path, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	token, method, _ := strings.Cut(strings.TrimPrefix(path, "bot"), "/")
+
+	r.ParseMultipartForm(32 << 20)
+	call := Call{Method: method, Token: token, Params: r.Form}
+
+	result, err := s.answer(r.Context(), call)
+	res := map[string]interface{}{"ok": err == nil}
+	if err != nil {
+		code := http.StatusBadRequest
+		if e, ok := err.(*Error); ok {
+			code = e.Code
+		}
+		res["error_code"], res["description"] = code, err.Error()
+		w.WriteHeader(code)
+	} else {
+		res["result"] = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// download writes the content of the stored file, the path is in the format
+// <TOKEN>/<FILE_PATH>
+func (s *Server) download(w http.ResponseWriter, r *http.Request, path string) {
+	_, filePath, _ := strings.Cut(path, "/")
+
+	s.mu.Lock()
+	content, ok := s.contents[filePath]
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write(content)
+}
+
+// answer records the call and generates its result
+func (s *Server) answer(ctx context.Context, call Call) (interface{}, error) {
+	if call.Method == "getUpdates" {
+		return s.getUpdates(ctx, call), nil
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, call)
+	s.changed()
+	fn := s.handlers[call.Method]
+	s.mu.Unlock()
+
+	if fn != nil {
+		return fn(call)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reply(call)
+}
+
+// reply generates the default result of the call, mu must be locked
+func (s *Server) reply(call Call) (interface{}, error) {
+	switch call.Method {
+	case "getMe":
+		return s.Me, nil
+
+	case "getFile":
+		file, ok := s.files[call.Params.Get("file_id")]
+		if !ok {
+			return nil, &Error{400, "Bad Request: invalid file_id"}
+		}
+		return file, nil
+
+	case "getChat":
+		return echotron.Chat{ID: call.ChatID(), Type: chatType(call.ChatID())}, nil
+
+	case "getChatMember":
+		userID, _ := strconv.ParseInt(call.Params.Get("user_id"), 10, 64)
+		return echotron.ChatMember{User: &echotron.User{ID: userID}, Status: "member"}, nil
+
+	case "editMessageText", "editMessageCaption", "editMessageMedia", "editMessageReplyMarkup":
+		if call.Params.Has("inline_message_id") {
+			return true, nil
+		}
+		msg, ok := s.messages[call.ChatID()][call.MessageID()]
+		if !ok {
+			return nil, &Error{400, "Bad Request: message to edit not found"}
+		}
+		switch call.Method {
+		case "editMessageText":
+			msg.Text = call.Params.Get("text")
+		case "editMessageCaption":
+			msg.Caption = call.Params.Get("caption")
+		}
+		msg.ReplyMarkup = inlineMarkup(call)
+		msg.EditDate = int(time.Now().Unix())
+		return msg, nil
+
+	case "deleteMessage":
+		if _, ok := s.messages[call.ChatID()][call.MessageID()]; !ok {
+			return nil, &Error{400, "Bad Request: message to delete not found"}
+		}
+		delete(s.messages[call.ChatID()], call.MessageID())
+		return true, nil
+
+	case "copyMessage":
+		return echotron.MessageID{MessageID: s.send(call).ID}, nil
+	}
+
+	if strings.HasPrefix(call.Method, "send") && call.Method != "sendChatAction" {
+		if call.Method == "sendMediaGroup" {
+			return []*echotron.Message{s.send(call)}, nil
+		}
+		return s.send(call), nil
+	}
+	return true, nil
+}
+
+// send stores and returns the message sent by the call, mu must be locked
+func (s *Server) send(call Call) *echotron.Message {
+	return s.store(&echotron.Message{
+		From:        &s.Me,
+		Chat:        echotron.Chat{ID: call.ChatID(), Type: chatType(call.ChatID())},
+		Text:        call.Params.Get("text"),
+		Caption:     call.Params.Get("caption"),
+		ReplyMarkup: inlineMarkup(call),
+	})
+}
+
+// getUpdates returns the queued updates, waiting for them until the timeout
+// of the call expires, the request is cancelled or the server is closed
+func (s *Server) getUpdates(ctx context.Context, call Call) []*echotron.Update {
+	offset, _ := strconv.Atoi(call.Params.Get("offset"))
+	timeout, _ := strconv.Atoi(call.Params.Get("timeout"))
+	expired := time.After(time.Duration(timeout) * time.Second)
+
+	for {
+		s.mu.Lock()
+		var pending []*echotron.Update
+		for _, update := range s.updates {
+			if update.ID >= offset {
+				pending = append(pending, update)
+			}
+		}
+		s.updates = pending
+		notify := s.notify
+		s.mu.Unlock()
+
+		if len(pending) > 0 || timeout == 0 {
+			return pending
+		}
+		select {
+		case <-notify:
+		case <-expired:
+			return nil
+		case <-s.closed:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// inlineMarkup returns the inline keyboard attached by the call, nil if missing
+func inlineMarkup(call Call) *echotron.InlineKeyboardMarkup {
+	if kbd := call.InlineKeyboard(); kbd != nil {
+		return &echotron.InlineKeyboardMarkup{InlineKeyboard: kbd}
+	}
+	return nil
+}
+
+// chatType guesses the type of the chat by its ID
+func chatType(chatID int64) string {
+	if chatID < 0 {
+		return "supergroup"
+	}
+	return "private"
+}
+
+// RoundTrip answers the requests using the server, without using the network.
+// It makes the server an http.RoundTripper, see NewServer
+func (s *Server) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return rec.Result(), nil
+}
@@ -1,7 +1,12 @@
 package robot
 
 import (
-	"log"
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/DazFather/parrbot/message"
@@ -9,54 +14,149 @@ import (
 	"github.com/NicoNex/echotron/v3"
 )
 
-var dsp *echotron.Dispatcher
-
 // Bot structure
 type Bot struct {
 	ChatID int64 // ChatID of the user who is using the bot on a private chat
+
+	robot *Robot // robot the session belongs to, see Robot
+	mu    sync.Mutex
+	next  *step                      // handler waiting for the next update, see Await and WaitFor
+	data  map[string]json.RawMessage // session data, see SetData and GetData
+
+	failures map[*message.Update]error // errors returned by the handlers, see HandlerFunc
 }
 
 // newBot Creates a new bot - will be called when a user first start the bot
-func newBot(chatID int64) echotron.Bot {
-	bot := &Bot{chatID}
-	if duration := Config.DeleteSessionTimer; duration != 0 {
-		go bot.selfDestruct(time.After(duration))
+func (d *dispatcher) newBot(chatID int64) *Bot {
+	bot := &Bot{ChatID: chatID, robot: d.robot}
+	if err := bot.loadData(); err != nil {
+		d.robot.Logger().Error("Unable to load session data", "chat_id", chatID, "error", err)
+	}
+	if duration := d.robot.config.DeleteSessionTimer; duration != 0 {
+		go bot.selfDestruct(d, time.After(duration))
 	}
 	return bot
 }
 
-func (b *Bot) selfDestruct(timech <-chan time.Time) {
+func (b *Bot) selfDestruct(d *dispatcher, timech <-chan time.Time) {
 	<-timech
-	dsp.DelSession(b.ChatID)
+	d.DelSession(b.ChatID)
+}
+
+// Robot returns the robot the session belongs to, the default one if the bot
+// has not been created by a robot
+func (b *Bot) Robot() *Robot {
+	if b == nil || b.robot == nil {
+		return std
+	}
+	return b.robot
 }
 
-// Update is used internally to manage the incoming inputs from Telegram
+// config returns the configuration of the robot the session belongs to
+func (b *Bot) config() *ParrbotConfig {
+	return b.Robot().config
+}
+
+// Logger returns the logger of the robot the session belongs to, see Config.Logger
+func (b *Bot) Logger() message.Logger {
+	return b.Robot().Logger()
+}
+
+// Update is used internally to manage the incoming inputs from Telegram.
+// Panics happening while handling the update are recovered and reported, also
+// to Config.OnError as a *PanicError
 func (b *Bot) Update(u *echotron.Update) {
-	var update = message.CastUpdate(u)
+	var (
+		update *message.Update
+		filter message.UpdateType
+		e      entry
+	)
+	defer func() {
+		if value := recover(); value != nil {
+			err := newPanicError(value)
+			if update != nil {
+				b.failed(update) // the panic replaces the handler error
+			}
+			b.reportPanic(err, update, u, "trigger", e.trigger)
+		}
+	}()
+
+	if rec := b.config().Recorder; rec != nil {
+		if err := rec.update(u); err != nil {
+			b.Logger().Error("Unable to record update", "chat_id", b.ChatID, "update_id", u.ID, "error", err)
+		}
+	}
+
+	if update = b.Robot().client.CastUpdate(u); update == nil {
+		return
+	}
 
-	fn := Select(update)
-	if fn == nil {
+	filter, e = b.route(update)
+	if metrics := b.config().Metrics; metrics != nil {
+		start := time.Now()
+		defer func() {
+			metrics.observeUpdate(filter, e.trigger, e.fn != nil, time.Since(start))
+		}()
+	}
+	if e.fn == nil {
 		return
 	}
 
-	if msg := fn(b, update); msg != nil {
-		msg.Send(b.ChatID)
+	b.send(update, e.fn(b, update), "trigger", e.trigger)
+}
+
+// route selects the command that will handle the given update: the waiting step
+// (see Await) if it carries no command, or the one triggered or matched (see
+// Command.Pattern) by it, or the fallback command. It also returns the type of
+// the update. The trigger of the selected entry is its metrics label
+func (b *Bot) route(update *message.Update) (message.UpdateType, entry) {
+	var (
+		r = b.Robot()
+		t = r.extract(update)
+	)
+
+	if t.trigger == "" {
+		if fn := b.popStep(t.filter); fn != nil {
+			return t.filter, entry{fn: fn, trigger: StepMetric}
+		}
+	}
+
+	if e := r.lookup(update, t); e.fn != nil {
+		return t.filter, e
+	}
+
+	e := r.fallback(t)
+	switch {
+	case e.fn == nil:
+		e.trigger = NoneMetric
+	case e.trigger == DefaultTrigger:
+		e.trigger = DefaultMetric
 	}
+	return t.filter, e
 }
 
 // Start give life to your amazing robo-parrot. It accepts the commands that the
 // bot will need to handle. This function will also load all configuartion available
 // int the Confing variable, so if you want to change them, do it before calling
-// this function. Start will also stop the flow of execution (unless bot crash)
-// if any error happens it will be logged on screen and progrm will terminate
-func Start(commandList ...Command) {
-	// Initialization
-	if err := Config.init(); err != nil {
-		log.Fatal("Config error: ", err)
-	}
-	LoadCommands(commandList)
-	dsp = echotron.NewDispatcher(Config.token, newBot)
-
-	// Put life into the bot
-	log.Println(dsp.Poll())
+// this function. Updates are received using long polling, unless Config.Webhook
+// is set. Start will also stop the flow of execution until the program receive
+// an interrupt (or SIGTERM) signal, then it will gracefully shutdown.
+// If any error happens it will be logged using Config.Logger and returned
+func Start(commandList ...Command) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := RunContext(ctx, commandList...)
+	if err != nil {
+		std.Logger().Error("Unable to run the bot", "error", err)
+	}
+	return err
+}
+
+// RunContext works as Start but it returns an error instead of terminating the
+// program and it stops receiving updates when the given context is done. Then
+// it waits for the running handlers to finish (until Config.ShutdownTimeout)
+// and saves the session data, before returning
+func RunContext(ctx context.Context, commandList ...Command) error {
+	return std.Run(ctx, commandList...)
 }
@@ -1,8 +1,9 @@
 package robot
 
 import (
-	"log"
+	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/DazFather/parrbot/message"
 
@@ -13,107 +14,255 @@ import (
 type Command struct {
 	Description string             // A description of the command that will be displayed on the "/" menu if the ReplyAt includes MESSAGE
 	Trigger     string             // Needs to start with the '/' character. Is the string that if contained at the start of the update would run the Scope
+	Aliases     []string           // Optional alternative triggers (ex. "/h" for "/help"), they will not appear on the "/" menu
+	IgnoreCase  bool               // When true Trigger and Aliases will match regardless of the case (ex. "/Help" or "/HELP")
+	Pattern     *regexp.Regexp     // Alternative to Trigger, the command will run when the text (or callback data) match it. See PathPattern
 	ReplyAt     message.UpdateType // Tells witch UpdateType(s) the bot will reply at, sum them to put more
 	CallFunc    CommandFunc        // The actual function that the bot will run
+	Handler     HandlerFunc        // Alternative to CallFunc that can also return an error, used only if CallFunc is nil
+	Middlewares []Middleware       // Optional middlewares that will wrap CallFunc (after the global ones on Config.Middlewares)
+	Chats       message.ChatType   // Tells in witch type(s) of chat the bot will reply, sum them to put more. By default (0) is any
+	Roles       Role               // Tells who can use the command, sum them to allow more. By default (0) is everyone. See OWNER, ADMIN, CHAT_ADMIN
+
+	Scopes       []echotron.BotCommandScope // Optional scopes where the command will appear on the "/" menu, by default all chats. See PrivateChatsScope, ChatScope...
+	Descriptions map[string]string          // Optional translations of Description, by IETF language code (ex. "it"), for the users with that language
+	Category     string                     // Optional group of the command on the help generated by tgui.HelpCommand
 }
 
+// These are the special triggers that can be used to declare the fallback
+// commands, that will run only when the update is not handled by any other
+// command. As for the others, there can be one for each UpdateType
+const (
+	// DefaultTrigger is the trigger of the command that will handle the updates
+	// that are not carrying any command (ex. free text, inline queries...)
+	DefaultTrigger = ""
+
+	// UnknownTrigger is the trigger of the command that will handle the updates
+	// carrying a command that is not in the command list (ex. "/unknown")
+	UnknownTrigger = "/*"
+)
+
 // CommandFunc is a custom type that rapresent a command that the bot should be able to run
 type CommandFunc func(*Bot, *message.Update) message.Any
 
-// commands is where all the command will be stored
-var commands map[message.UpdateType]map[string]CommandFunc
+// entry is a command of the command list in the form used by Select
+type entry struct {
+	fn      CommandFunc
+	trigger string // Trigger of the command, or its Pattern when missing
+	pattern *regexp.Regexp
+	chats   message.ChatType
+}
 
-// divide the command list and cast it in a form that is more efficenct
-func divide(commandList []Command) (splitted map[message.UpdateType]map[string]CommandFunc) {
-	splitted = make(map[message.UpdateType]map[string]CommandFunc, 0)
+// accepts returns true if the command can reply in the given type of chat
+func (e entry) accepts(chat message.ChatType) bool {
+	return e.chats == 0 || chat == 0 || e.chats&chat != 0
+}
 
-	var cmdMenu []echotron.BotCommand
+// pick returns the first entry that can reply in the given type of chat, or an
+// entry without function if there are none
+func pick(entries []entry, chat message.ChatType) entry {
+	for _, e := range entries {
+		if e.accepts(chat) {
+			return e
+		}
+	}
+	return entry{}
+}
+
+// commandTable contains the commands of the command list divided by UpdateType
+// in the forms used by Select
+type commandTable struct {
+	triggers map[message.UpdateType]map[string][]entry // commands by trigger (and aliases)
+	folded   map[message.UpdateType]map[string][]entry // commands with IgnoreCase by lower case trigger (and aliases)
+	patterns map[message.UpdateType][]entry            // commands with a Pattern, in the same order of the command list
+}
+
+// add adds the entry to the table by the given trigger
+func add(table map[message.UpdateType]map[string][]entry, filter message.UpdateType, trigger string, e entry) {
+	if table[filter] == nil {
+		table[filter] = make(map[string][]entry, 0)
+	}
+	table[filter][trigger] = append(table[filter][trigger], e)
+}
+
+// divide the command list and cast it in a form that is more efficenct
+func (r *Robot) divide(commandList []Command) (table commandTable, err error) {
+	table = commandTable{
+		triggers: make(map[message.UpdateType]map[string][]entry, 0),
+		folded:   make(map[message.UpdateType]map[string][]entry, 0),
+		patterns: make(map[message.UpdateType][]entry, 0),
+	}
 
 	for _, cmd := range commandList {
+		var fn = cmd.CallFunc
+		if fn == nil && cmd.Handler != nil {
+			fn = cmd.Handler.CommandFunc()
+		}
 
-		if cmd.ReplyAt&message.MESSAGE != 0 && cmd.Trigger != "" && cmd.Description != "" {
-			cmdMenu = append(cmdMenu, echotron.BotCommand{
-				Command:     cmd.Trigger,
-				Description: cmd.Description,
-			})
+		var e = entry{
+			fn:      Chain(restrict(fn, cmd.Roles), append(append([]Middleware{}, r.config.Middlewares...), cmd.Middlewares...)...),
+			trigger: cmd.Trigger,
+			pattern: cmd.Pattern,
+			chats:   cmd.Chats,
+		}
+		if e.trigger == "" && cmd.Pattern != nil {
+			e.trigger = cmd.Pattern.String()
 		}
 
-		for i := 0; i <= 9; i++ {
-			t := message.UpdateType(1 << i)
-			if cmd.ReplyAt&t != 0 {
-				if m := splitted[t]; m == nil {
-					splitted[t] = make(map[string]CommandFunc, 0)
+		for t := message.UpdateType(1); t <= message.ANY; t <<= 1 {
+			if cmd.ReplyAt&t == 0 {
+				continue
+			}
+
+			if cmd.Pattern != nil {
+				table.patterns[t] = append(table.patterns[t], e)
+				continue
+			}
+
+			for _, trigger := range append([]string{cmd.Trigger}, cmd.Aliases...) {
+				if cmd.IgnoreCase && !isFallback(trigger) {
+					add(table.folded, t, strings.ToLower(trigger), e)
+				} else {
+					add(table.triggers, t, trigger, e)
 				}
-				splitted[t][cmd.Trigger] = cmd.CallFunc
 			}
 		}
 	}
 
-	if len(cmdMenu) == 0 {
-		return
+	keys, menus := buildMenus(commandList)
+	for _, key := range keys {
+		if err = r.setMyCommands(key, menus[key]); err != nil {
+			return table, fmt.Errorf("SetMyCommands error: %w", err)
+		}
+	}
+
+	return
+}
+
+// Select take an update and verify it's type and then trigger (or pattern) in
+// order to return the appropriate function (or nil). If robot.Start is used
+// (as racommanded), probably, there is no need to use this function
+func Select(update *message.Update) CommandFunc {
+	return std.Select(update)
+}
+
+// Select works as the Select function, but using the commands of the robot
+func (r *Robot) Select(update *message.Update) CommandFunc {
+	var t = r.extract(update)
+	if e := r.lookup(update, t); e.fn != nil {
+		return e.fn
 	}
+	return r.fallback(t).fn
+}
 
-	res, err := message.API().SetMyCommands(nil, cmdMenu...)
-	if err != nil {
-		log.Fatal("SetMyCommands error: ", err)
+// fallback returns the fallback command for the given target: the one with
+// UnknownTrigger if a trigger is given, the one with DefaultTrigger otherwise
+func (r *Robot) fallback(t target) entry {
+	if t.trigger != "" {
+		return pick(r.commands.triggers[t.filter][UnknownTrigger], t.chat)
 	}
-	if res.Result != true || res.Ok != true {
-		log.Fatal("SetMyCommands wrong response: ", res)
+	return pick(r.commands.triggers[t.filter][DefaultTrigger], t.chat)
+}
+
+// isFallback returns true if the given trigger is one of the fallback ones
+func isFallback(trigger string) bool {
+	return trigger == DefaultTrigger || trigger == UnknownTrigger
+}
+
+// lookup searches for the command triggered by the given update, first by the
+// exact trigger, then ignoring the case and finally by pattern. In the latter
+// case the params are saved on update
+func (r *Robot) lookup(update *message.Update, t target) entry {
+	if t.trigger != "" {
+		if e := pick(r.commands.triggers[t.filter][t.trigger], t.chat); e.fn != nil {
+			return e
+		}
+		if e := pick(r.commands.folded[t.filter][strings.ToLower(t.trigger)], t.chat); e.fn != nil {
+			return e
+		}
 	}
 
-	return
+	e, params := r.matchPattern(t)
+	if e.fn != nil {
+		update.Params = params
+	}
+	return e
 }
 
-// Select take an update and verify it's type and then trigger in order to return
-// the appropriate function (or nil). If robot.Start is used (as racommanded),
-// probably, there is no need to use this function
-func Select(update *message.Update) CommandFunc {
-	var (
-		rgx     = regexp.MustCompile(`^/\w+`)
-		trigger string
-		filter  message.UpdateType
-	)
+// target contains the informations of an update that are needed to select a command
+type target struct {
+	filter  message.UpdateType // type of the update, 0 if it must be ignored
+	chat    message.ChatType   // type of the chat, 0 if unknown
+	trigger string             // command carried by the update (without the bot's username)
+	text    string             // text that can be matched by the commands Pattern
+}
+
+// extract returns the target of the given update. When the update carries a
+// command addressed to another bot (ex. "/start@OtherBot") the filter will be
+// 0, so that no command will be selected
+func (r *Robot) extract(update *message.Update) (t target) {
+	var rgx = regexp.MustCompile(`^(/\w+)(@\w+)?`)
 
 	switch true {
 	case update.Message != nil:
-		trigger = rgx.FindString(update.Message.Text)
-		filter = message.MESSAGE
+		t.text = update.Message.Text
+		t.filter = message.MESSAGE
 	case update.EditedMessage != nil:
-		trigger = rgx.FindString(update.EditedMessage.Text)
-		filter = message.EDITED_MESSAGE
+		t.text = update.EditedMessage.Text
+		t.filter = message.EDITED_MESSAGE
 	case update.ChannelPost != nil:
-		trigger = rgx.FindString(update.ChannelPost.Text)
-		filter = message.CHANNEL_POST
+		t.text = update.ChannelPost.Text
+		t.filter = message.CHANNEL_POST
 	case update.EditedChannelPost != nil:
-		trigger = rgx.FindString(update.EditedChannelPost.Text)
-		filter = message.EDITED_CHANNEL_POST
+		t.text = update.EditedChannelPost.Text
+		t.filter = message.EDITED_CHANNEL_POST
 	case update.InlineQuery != nil:
-		filter = message.INLINE_QUERY
+		return target{filter: message.INLINE_QUERY, chat: update.ChatType(), text: update.InlineQuery.Query}
 	case update.ChosenInlineResult != nil:
-		filter = message.CHOSEN_INLINE_RESULT
+		return target{filter: message.CHOSEN_INLINE_RESULT, text: update.ChosenInlineResult.Query}
 	case update.CallbackQuery != nil:
-		trigger = rgx.FindString(update.CallbackQuery.Data)
-		filter = message.CALLBACK_QUERY
+		t.text = update.CallbackQuery.Data
+		t.filter = message.CALLBACK_QUERY
 	case update.ShippingQuery != nil:
-		filter = message.SHIPPING_QUERY
+		t.filter = message.SHIPPING_QUERY
 	case update.PreCheckoutQuery != nil:
-		filter = message.PRE_CHECKOUT_QUERY
+		t.filter = message.PRE_CHECKOUT_QUERY
 	case update.MyChatMember != nil:
-		filter = message.MY_CHAT_MEMBER
+		t.filter = message.MY_CHAT_MEMBER
 	case update.ChatMember != nil:
-		filter = message.CHAT_MEMBER
+		t.filter = message.CHAT_MEMBER
 	case update.ChatJoinRequest != nil:
-		filter = message.CHAT_JOIN_REQUEST
+		t.filter = message.CHAT_JOIN_REQUEST
 	}
+	t.chat = update.ChatType()
 
-	return commands[filter][trigger]
+	if match := rgx.FindStringSubmatch(t.text); match != nil {
+		if mention := match[2]; mention != "" {
+			if !r.isMe(mention[1:]) {
+				return target{}
+			}
+			// Remove the username so that patterns works the same in every chat
+			t.text = match[1] + t.text[len(match[0]):]
+		}
+		t.trigger = match[1]
+	}
+
+	return
 }
 
 // LoadCommands saves the given commandList in a form that is more efficenct for
 // the bot to retrive. Use this function one time only, is necessary for Select
 // to work. If robot.Start is used (as racommanded), probably, there is no need
-// to use this function
+// to use this function. Errors are logged using Config.Logger
 func LoadCommands(commandList []Command) {
-	commands = make(map[message.UpdateType]map[string]CommandFunc, 0)
-	commands = divide(commandList)
+	if err := std.LoadCommands(commandList); err != nil {
+		std.Logger().Error("Unable to load the commands", "error", err)
+	}
+}
+
+// LoadCommands works as the LoadCommands function for the robot, but returns
+// the error. If Run is used, there is no need to use this method
+func (r *Robot) LoadCommands(commandList []Command) (err error) {
+	r.commands, err = r.divide(commandList)
+	return
 }
@@ -0,0 +1,121 @@
+package robot_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/robot"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// reply returns a CommandFunc that prints the given text
+func reply(text string) robot.CommandFunc {
+	return func(*robot.Bot, *message.Update) message.Any {
+		fmt.Println(text)
+		return nil
+	}
+}
+
+func ExampleUnknownTrigger() {
+	robot.LoadCommands([]robot.Command{
+		{Trigger: "/start", ReplyAt: message.MESSAGE, CallFunc: reply("start")},
+		{Trigger: robot.UnknownTrigger, ReplyAt: message.MESSAGE, CallFunc: reply("I don't know this command")},
+		{Trigger: robot.DefaultTrigger, ReplyAt: message.MESSAGE, CallFunc: reply("I don't understand")},
+	})
+
+	for _, text := range []string{"/start", "/unknown", "hello"} {
+		update := &message.Update{Message: &message.UpdateMessage{Text: text}}
+		robot.Select(update)(nil, update)
+	}
+	// Output:
+	// start
+	// I don't know this command
+	// I don't understand
+}
+
+func ExampleCommand() {
+	robot.LoadCommands([]robot.Command{
+		{Trigger: "/start", ReplyAt: message.MESSAGE, Chats: message.PRIVATE_CHAT, CallFunc: reply("hello user")},
+		{Trigger: "/start", ReplyAt: message.MESSAGE, Chats: message.GROUP_CHAT + message.SUPERGROUP_CHAT, CallFunc: reply("hello group")},
+	})
+
+	for _, chatType := range []string{"private", "supergroup", "channel"} {
+		update := &message.Update{Message: &message.UpdateMessage{
+			Text: "/start",
+			Chat: &echotron.Chat{Type: chatType},
+		}}
+		if fn := robot.Select(update); fn != nil {
+			fn(nil, update)
+		} else {
+			fmt.Println("no reply in", chatType)
+		}
+	}
+	// Output:
+	// hello user
+	// hello group
+	// no reply in channel
+}
+
+func ExampleCommand_aliases() {
+	robot.LoadCommands([]robot.Command{{
+		Trigger:    "/help",
+		Aliases:    []string{"/h", "/aiuto"},
+		IgnoreCase: true,
+		ReplyAt:    message.MESSAGE,
+		CallFunc: func(*robot.Bot, *message.Update) message.Any {
+			fmt.Println("help")
+			return nil
+		},
+	}})
+
+	for _, text := range []string{"/help", "/H", "/Aiuto", "/helpme"} {
+		update := &message.Update{Message: &message.UpdateMessage{Text: text}}
+		if fn := robot.Select(update); fn != nil {
+			fn(nil, update)
+		} else {
+			fmt.Println("unknown", text)
+		}
+	}
+	// Output:
+	// help
+	// help
+	// help
+	// unknown /helpme
+}
+
+func TestCommandMentions(t *testing.T) {
+	d := startDriver(t,
+		robot.Command{Trigger: "/start", ReplyAt: message.MESSAGE, CallFunc: answer("started")},
+		robot.Command{Pattern: robot.PathPattern("/item :id"), ReplyAt: message.MESSAGE, CallFunc: answer("item")},
+		robot.Command{Trigger: robot.UnknownTrigger, ReplyAt: message.MESSAGE, CallFunc: answer("unknown")},
+	)
+
+	cases := []struct {
+		text, reply string // empty reply if ignored
+	}{
+		{"/start", "started"},
+		{"/start@parrbot", "started"},
+		{"/start@ParrBot", "started"},
+		{"/start@otherbot", ""},
+		{"/item@parrbot 42", "item"},
+		{"/item@otherbot 42", ""},
+		{"/unknown@parrbot", "unknown"},
+		{"/unknown@otherbot", ""},
+	}
+	for _, c := range cases {
+		d.Reset()
+		if _, err := d.SendMessage(-100, c.text); err != nil {
+			t.Fatal(err)
+		}
+
+		var reply string
+		if call, ok := d.Last(); ok {
+			reply = call.Text()
+		}
+		if reply != c.reply {
+			t.Errorf("reply to %q = %q, want %q", c.text, reply, c.reply)
+		}
+	}
+}
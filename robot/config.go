@@ -1,21 +1,46 @@
 package robot
 
 import (
+	"errors"
 	"time"
 
 	"github.com/DazFather/parrbot/message"
 )
 
 // Config contains all the default Parrbot configurations. Edit them before robot.Start
-var Config = ParrbotConfig{
-	DeleteSessionTimer: time.Hour * 2,
-	// by default token will be loaded using os.Args
+var Config = DefaultConfig()
+
+// DefaultConfig returns a new configuration with the default values, each one
+// with its own SessionStore in memory. Useful to create a Robot using New
+func DefaultConfig() ParrbotConfig {
+	return ParrbotConfig{
+		DeleteSessionTimer: time.Hour * 2,
+		ShutdownTimeout:    time.Second * 10,
+		SessionStore:       NewMemoryStore(),
+		ChatAdminsCacheTTL: time.Minute * 5,
+		// by default token will be loaded using os.Args
+	}
 }
 
 // ParrbotConfig defines all the possible configurations of your parr-bot
 type ParrbotConfig struct {
-	DeleteSessionTimer time.Duration // time after witch the bot session will self distruct by the dispatcher
-	token              string        // Telegram API bot's token.
+	DeleteSessionTimer time.Duration  // time after witch the bot session will self distruct by the dispatcher
+	ShutdownTimeout    time.Duration  // max time to wait for the running handlers when the bot stops, 0 means no limit
+	Webhook            *WebhookConfig // when not nil the bot will receive updates via webhook instead of long polling
+	DropPendingUpdates bool           // when true the updates received while the bot was not running are ignored (long polling only, see WebhookConfig)
+	Middlewares        []Middleware   // middlewares that will wrap every command, the first one is the outermost
+	SessionStore       SessionStore   // where the session data is kept, by default in memory. See NewFileStore
+	DeveloperChatID    int64          // when not 0, panics happened inside handlers will be reported on this chat
+	OnError            ErrorHandler   // called when a handler fails or its message can't be sent, by default errors are logged
+	OwnerID            int64          // ID of the user with the OWNER role, that can use every command
+	AdminIDs           []int64        // IDs of the users with the ADMIN role
+	ChatAdminsCacheTTL time.Duration  // how long the bot remembers if a user is an administrator of a chat (CHAT_ADMIN role)
+	Unauthorized       CommandFunc    // runs instead of the command when the user doesn't have its Roles, by default callback queries get an alert
+	Recorder           *Recorder      // when not nil every incoming update is recorded, to be replayed later. See NewRecorder
+	Metrics            *Metrics       // when not nil updates, handlers and requests to Telegram are measured. See NewMetrics
+	Logger             message.Logger // where the framework writes what goes wrong, ex. a *slog.Logger. By default the standard log package is used
+	token              string         // Telegram API bot's token.
+	flags              *flagSettings  // configurations given by the command line flags, see RegisterFlags
 }
 
 // KeepActiveSessions sets the DeleteSessionTimer = 0 causing all session to stay active
@@ -23,13 +48,29 @@ func (c *ParrbotConfig) KeepActiveSessions() {
 	c.DeleteSessionTimer = 0
 }
 
+// UseWebhook makes the bot receive the updates via webhook at the given public
+// URL instead of long polling. If secretToken is not empty, every request not
+// carrying it will be refused. The server uses plain HTTP on the port of the URL,
+// behind a TLS-terminating proxy: use the Webhook field for more options, like
+// the certificate to serve HTTPS directly (see WebhookConfig)
+func (c *ParrbotConfig) UseWebhook(url, secretToken string) error {
+	webhook := &WebhookConfig{URL: url, SecretToken: secretToken}
+	if err := webhook.validate(); err != nil {
+		return err
+	}
+	c.Webhook = webhook
+	return nil
+}
+
 // SetAPIToken sets the Telegram Bot API token to the given one if valid
 func (c *ParrbotConfig) SetAPIToken(token string) error {
 	if err := validateToken(token); err != nil {
 		return err
 	}
 	c.token = token
-	message.LoadAPI(token)
+	if c == &Config {
+		message.LoadAPI(token)
+	}
 	return nil
 }
 
@@ -42,11 +83,27 @@ func (c *ParrbotConfig) loadDefaultToken() error {
 	return err
 }
 
-// init is used to initialize a ParrbotConfig to
-func (c *ParrbotConfig) init() error {
-	// if token is un-initilized load default
+// init is used to initialize a ParrbotConfig to. The configuration sources
+// (environment, flags and program arguments) are used only if external is true
+func (c *ParrbotConfig) init(external bool) error {
+	if external {
+		if err := c.load(); err != nil {
+			return err
+		}
+	}
+
+	// if token is still un-initilized load default (only without flags, as they would be in os.Args)
 	if c.token == "" {
-		return c.loadDefaultToken()
+		if !external || c.flags != nil {
+			return errors.New("Missing TOKEN value")
+		}
+		if err := c.loadDefaultToken(); err != nil {
+			return err
+		}
+	}
+
+	if c.Webhook != nil {
+		return c.Webhook.validate()
 	}
 
 	return nil
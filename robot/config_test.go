@@ -1,7 +1,10 @@
 package robot_test
 
 import (
+	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/DazFather/parrbot/robot"
 )
@@ -28,3 +31,44 @@ func ExampleSetAPIToken() {
 	// Wrong format for TOKEN value
 	// <nil>
 }
+
+func ExampleParrbotConfig_UseWebhook() {
+	var err error
+
+	err = robot.Config.UseWebhook("example.com/parrbot", "")
+	fmt.Println(err)
+
+	err = robot.Config.UseWebhook("https://example.com:8443/parrbot", "my-secret_token")
+	fmt.Println(err, robot.Config.Webhook.ListenAddr)
+	robot.Config.Webhook = nil // back to long polling
+
+	// Output:
+	// Webhook URL must start with https://
+	// <nil> :8443
+}
+
+func ExampleParrbotConfig_RegisterFlags() {
+	var (
+		config  robot.ParrbotConfig
+		set     = flag.NewFlagSet("mybot", flag.ContinueOnError)
+		verbose = set.Bool("verbose", false, "flag of the application")
+	)
+
+	config.RegisterFlags(set, "bot-")
+	err := set.Parse([]string{"-verbose", "-bot-token", "123:ABC", "-bot-owner-id", "42"})
+
+	fmt.Println(err, *verbose)
+	// Output: <nil> true
+}
+
+func ExampleParrbotConfig_LoadFile() {
+	path := filepath.Join(os.TempDir(), "parrbot-example.json")
+	defer os.Remove(path)
+	os.WriteFile(path, []byte(`{"token": "123:ABC", "owner_id": 42, "delete_session_timer": "1h"}`), 0600)
+
+	var config robot.ParrbotConfig
+	err := config.LoadFile(path)
+
+	fmt.Println(err, config.OwnerID, config.DeleteSessionTimer)
+	// Output: <nil> 42 1h0m0s
+}
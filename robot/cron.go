@@ -0,0 +1,139 @@
+package robot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed cron expression, see ParseCron
+type Cron struct {
+	minute, hour, dom, month, dow uint64 // bit set of the allowed values
+	anyDom, anyDow                bool   // true if the field is "*"
+}
+
+// cronFields are the bounds of the fields of a cron expression
+var cronFields = [5]struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseCron parses a standard cron expression of 5 fields separated by spaces:
+// minute, hour, day of month, month and day of week (0 or 7 is Sunday). Each
+// field can be "*", a value, a range ("1-5"), a list ("1,3,5") and can have a
+// step ("*/15", "0-30/10", "5/10" that is "5-59/10"). As usual when both day of month and day of week are
+// not "*", the time matches when any of them does
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errors.New("Cron expression must have 5 fields: minute hour day-of-month month day-of-week")
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s %q: %w", cronFields[i].name, field, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday can be both 0 and 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return &Cron{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		anyDom: fields[2] == "*",
+		anyDow: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField returns the bit set of the values allowed by the given field
+func parseCronField(field string, min, max int) (set uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		var (
+			step    = 1
+			stepped bool
+		)
+		if rng, s, found := strings.Cut(part, "/"); found {
+			if step, err = strconv.Atoi(s); err != nil || step <= 0 {
+				return 0, errors.New("invalid step")
+			}
+			part, stepped = rng, true
+		}
+
+		var from, to = min, max
+		if part != "*" {
+			first, last, isRange := strings.Cut(part, "-")
+			if from, err = strconv.Atoi(first); err != nil {
+				return 0, errors.New("invalid value")
+			}
+			// a single value with a step means from that value to the max ("5/10")
+			if !stepped {
+				to = from
+			}
+			if isRange {
+				if to, err = strconv.Atoi(last); err != nil {
+					return 0, errors.New("invalid value")
+				}
+			}
+		}
+		if from < min || to > max || from > to {
+			return 0, fmt.Errorf("values must be between %d and %d", min, max)
+		}
+
+		for v := from; v <= to; v += step {
+			set |= 1 << v
+		}
+	}
+	return
+}
+
+// has returns true if the value is contained on the set
+func has(set uint64, value int) bool {
+	return set&(1<<value) != 0
+}
+
+// matchDay returns true if the day of t matches both the day of month and day of week fields
+func (c Cron) matchDay(t time.Time) bool {
+	dom, dow := has(c.dom, t.Day()), has(c.dow, int(t.Weekday()))
+	if !c.anyDom && !c.anyDow {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// Next returns the first time after t that matches the expression, or the zero
+// time if there is none in the next 5 years (ex. "0 0 30 2 *")
+func (c Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		switch {
+		case !has(c.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !has(c.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !has(c.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
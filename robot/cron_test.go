@@ -0,0 +1,90 @@
+package robot_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DazFather/parrbot/robot"
+)
+
+func ExampleParseCron() {
+	// Every 15 minutes during working hours, from Monday to Friday
+	cron, err := robot.ParseCron("*/15 9-17 * * 1-5")
+	if err != nil {
+		panic(err)
+	}
+
+	saturday := time.Date(2022, time.October, 15, 12, 0, 0, 0, time.UTC)
+	fmt.Println(cron.Next(saturday))
+	// Output: 2022-10-17 09:00:00 +0000 UTC
+}
+
+func TestParseCron(t *testing.T) {
+	var (
+		from = time.Date(2022, time.October, 15, 12, 0, 0, 0, time.UTC) // Saturday
+		date = func(month time.Month, day, hour, minute int) time.Time {
+			return time.Date(2022, month, day, hour, minute, 0, 0, time.UTC)
+		}
+	)
+	cases := []struct {
+		expr string
+		next []time.Time
+	}{
+		{"* * * * *", []time.Time{date(10, 15, 12, 1), date(10, 15, 12, 2)}},
+		{"*/20 * * * *", []time.Time{date(10, 15, 12, 20), date(10, 15, 12, 40), date(10, 15, 13, 0)}},
+		// A step without a range goes up to the max
+		{"5/20 * * * *", []time.Time{date(10, 15, 12, 5), date(10, 15, 12, 25), date(10, 15, 12, 45), date(10, 15, 13, 5)}},
+		{"0 22/1 * * *", []time.Time{date(10, 15, 22, 0), date(10, 15, 23, 0), date(10, 16, 22, 0)}},
+		{"0-30/15 9 * * *", []time.Time{date(10, 16, 9, 0), date(10, 16, 9, 15), date(10, 16, 9, 30), date(10, 17, 9, 0)}},
+		{"0 9,18 * * *", []time.Time{date(10, 15, 18, 0), date(10, 16, 9, 0)}},
+		// Sunday is both 0 and 7
+		{"0 8 * * 7", []time.Time{date(10, 16, 8, 0), date(10, 23, 8, 0)}},
+		{"0 8 * * 0", []time.Time{date(10, 16, 8, 0), date(10, 23, 8, 0)}},
+		// Day of month and day of week are OR-ed when both are set
+		{"0 0 20 * 1", []time.Time{date(10, 17, 0, 0), date(10, 20, 0, 0), date(10, 24, 0, 0)}},
+		// Otherwise both must match
+		{"0 0 * 10 1", []time.Time{date(10, 17, 0, 0), date(10, 24, 0, 0), date(10, 31, 0, 0), time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC)}},
+		// Months without the day are skipped
+		{"0 0 31 * *", []time.Time{date(10, 31, 0, 0), date(12, 31, 0, 0), time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2023, 3, 31, 0, 0, 0, 0, time.UTC)}},
+		{"30 23 * 12 *", []time.Time{date(12, 1, 23, 30), date(12, 2, 23, 30)}},
+		{"0 0 29 2 *", []time.Time{time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)}},
+		{"0 0 30 2 *", []time.Time{{}}},
+	}
+
+	for _, c := range cases {
+		cron, err := robot.ParseCron(c.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q) returned %v", c.expr, err)
+			continue
+		}
+		for t0, i := from, 0; i < len(c.next); i++ {
+			if t0 = cron.Next(t0); !t0.Equal(c.next[i]) {
+				t.Errorf("run %d of %q = %v, want %v", i, c.expr, t0, c.next[i])
+				break
+			}
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-b * * * *",
+		"*/x * * * *",
+	} {
+		if _, err := robot.ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", expr)
+		}
+	}
+}
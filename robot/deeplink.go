@@ -0,0 +1,107 @@
+package robot
+
+import (
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/DazFather/parrbot/message"
+)
+
+// MaxPayloadLength is the max length allowed by Telegram for a deep link payload
+const MaxPayloadLength = 64
+
+// payloadRgx matches the characters allowed by Telegram in a deep link payload
+var payloadRgx = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
+
+// EncodePayload encodes any data (ex. "user:42") using base64url, so that it can
+// be used as payload of a deep link. Decode it using DecodePayload
+func EncodePayload(data string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(data))
+}
+
+// DecodePayload decodes a payload encoded by EncodePayload
+func DecodePayload(payload string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	return string(data), err
+}
+
+// DeepLink returns the link that starts a private chat with the bot sending
+// "/start <payload>", ex. "https://t.me/MyBot?start=ref_123". The payload can
+// be empty or up to MaxPayloadLength characters among A-Z, a-z, 0-9, _ and -.
+// Use EncodePayload for any other data. The username of the bot is needed, so
+// it will fail before Start
+func DeepLink(payload string) (string, error) {
+	return std.DeepLink(payload)
+}
+
+// DeepLink works as the DeepLink function, but using the username of the robot
+func (r *Robot) DeepLink(payload string) (string, error) {
+	return r.link("start", payload)
+}
+
+// GroupDeepLink returns the link that asks the user to add the bot to a group,
+// where it will receive "/start <payload>". The payload follows the same rules
+// of DeepLink
+func GroupDeepLink(payload string) (string, error) {
+	return std.GroupDeepLink(payload)
+}
+
+// GroupDeepLink works as the GroupDeepLink function, but using the username of the robot
+func (r *Robot) GroupDeepLink(payload string) (string, error) {
+	return r.link("startgroup", payload)
+}
+
+// link returns the t.me link of the robot with the payload as the given parameter
+func (r *Robot) link(param, payload string) (string, error) {
+	username := r.Username()
+	if username == "" {
+		return "", errors.New("Username of the bot is not known yet")
+	}
+	if len(payload) > MaxPayloadLength {
+		return "", errors.New("Payload is longer than 64 characters")
+	}
+	if !payloadRgx.MatchString(payload) {
+		return "", errors.New("Payload can only contain A-Z, a-z, 0-9, _ and -")
+	}
+
+	link := "https://t.me/" + username
+	if payload != "" || param == "startgroup" {
+		link += "?" + param + "=" + payload
+	}
+	return link, nil
+}
+
+// StartRouter creates a CommandFunc, to use on the "/start" command, that passes
+// the update to the function of the route whose key is the longest prefix of the
+// payload of the deep link (see message.Update.StartPayload). The rest of the
+// payload is available as update.Param("payload"). The route with the empty key,
+// if any, handles "/start" without payload or with an unknown one, otherwise
+// nothing is done, ex:
+//
+//	robot.StartRouter(map[string]robot.CommandFunc{"": welcome, "ref_": referral})
+func StartRouter(routes map[string]CommandFunc) CommandFunc {
+	return func(bot *Bot, update *message.Update) message.Any {
+		var (
+			payload = update.StartPayload()
+			prefix  string
+			found   bool
+		)
+
+		for key := range routes {
+			if strings.HasPrefix(payload, key) && (!found || len(key) > len(prefix)) {
+				prefix, found = key, true
+			}
+		}
+		if !found || routes[prefix] == nil {
+			return nil
+		}
+
+		if update.Params == nil {
+			update.Params = make(map[string]string)
+		}
+		update.Params["payload"] = strings.TrimPrefix(payload, prefix)
+		return routes[prefix](bot, update)
+	}
+}
@@ -0,0 +1,53 @@
+package robot_test
+
+import (
+	"fmt"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+)
+
+func ExampleStartRouter() {
+	greet := func(text string) robot.CommandFunc {
+		return func(bot *robot.Bot, update *message.Update) message.Any {
+			return message.Text{Text: text + update.Param("payload")}
+		}
+	}
+	invite := func(bot *robot.Bot, update *message.Update) message.Any {
+		code, err := robot.DecodePayload(update.Param("payload"))
+		if err != nil {
+			return message.Text{Text: "Invalid invite"}
+		}
+		return message.Text{Text: "Invited by " + code}
+	}
+
+	d, err := parrbottest.Start(robot.DefaultConfig(), robot.Command{
+		Trigger: "/start",
+		ReplyAt: message.MESSAGE,
+		CallFunc: robot.StartRouter(map[string]robot.CommandFunc{
+			"":     greet("Welcome"),
+			"ref_": greet("Referred by "),
+			"inv_": invite,
+		}),
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer d.Close()
+
+	link, _ := d.Robot.DeepLink("inv_" + robot.EncodePayload("Alice & Bob"))
+	fmt.Println(link)
+
+	for _, text := range []string{"/start", "/start ref_123", "/start inv_QWxpY2UgJiBCb2I"} {
+		d.SendMessage(42, text)
+		sent, _ := d.LastMessage(42)
+		fmt.Println(sent.Text)
+	}
+	// Output:
+	// https://t.me/parrbot?start=inv_QWxpY2UgJiBCb2I
+	// Welcome
+	// Referred by 123
+	// Invited by Alice & Bob
+}
@@ -0,0 +1,201 @@
+package robot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// dispatcher passes the incoming updates to the Bot (session) of their chat,
+// creating it if needed, and keeps track of the handlers that are still running
+type dispatcher struct {
+	robot    *Robot
+	mu       sync.Mutex
+	sessions map[int64]*Bot
+	inflight sync.WaitGroup
+}
+
+// newDispatcher creates a dispatcher without sessions for the given robot
+func newDispatcher(r *Robot) *dispatcher {
+	return &dispatcher{robot: r, sessions: make(map[int64]*Bot)}
+}
+
+// instance returns the session of the given chat, creating it if missing
+func (d *dispatcher) instance(chatID int64) *Bot {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	bot, ok := d.sessions[chatID]
+	if !ok {
+		bot = d.newBot(chatID)
+		d.sessions[chatID] = bot
+	}
+	return bot
+}
+
+// DelSession deletes the session of the given chat. Session data will still
+// be available on Config.SessionStore
+func (d *dispatcher) DelSession(chatID int64) {
+	d.mu.Lock()
+	delete(d.sessions, chatID)
+	d.mu.Unlock()
+}
+
+// dispatch runs the Update method of the session of the chat where the update
+// has been generated on a new goroutine
+func (d *dispatcher) dispatch(update *echotron.Update) {
+	chatID, ok := chatOf(update)
+	if !ok {
+		return
+	}
+
+	bot := d.instance(chatID)
+	d.inflight.Add(1)
+	go func() {
+		defer d.inflight.Done()
+		bot.Update(update)
+	}()
+}
+
+// wait waits for the running handlers to finish, until the given timeout
+// expires (0 means no timeout)
+func (d *dispatcher) wait(timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		d.inflight.Wait()
+		close(done)
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		expired = time.After(timeout)
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-expired:
+		return errors.New("Shutdown timeout: some handlers are still running")
+	}
+}
+
+// flush saves the data of all the sessions and close the session store if it
+// implements io.Closer
+func (d *dispatcher) flush() (err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, bot := range d.sessions {
+		bot.mu.Lock()
+		if len(bot.data) > 0 {
+			if e := bot.saveData(); e != nil {
+				err = e
+			}
+		}
+		bot.mu.Unlock()
+	}
+
+	if closer, ok := d.robot.config.SessionStore.(io.Closer); ok {
+		if e := closer.Close(); e != nil {
+			err = e
+		}
+	}
+	return
+}
+
+// chatOf returns the ID of the chat where the update has been generated
+func chatOf(update *echotron.Update) (int64, bool) {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID, true
+	case update.EditedMessage != nil:
+		return update.EditedMessage.Chat.ID, true
+	case update.ChannelPost != nil:
+		return update.ChannelPost.Chat.ID, true
+	case update.EditedChannelPost != nil:
+		return update.EditedChannelPost.Chat.ID, true
+	case update.InlineQuery != nil:
+		return update.InlineQuery.From.ID, true
+	case update.ChosenInlineResult != nil:
+		return update.ChosenInlineResult.From.ID, true
+	case update.CallbackQuery != nil:
+		if msg := update.CallbackQuery.Message; msg != nil {
+			return msg.Chat.ID, true
+		}
+		return update.CallbackQuery.From.ID, true
+	case update.ShippingQuery != nil:
+		return update.ShippingQuery.From.ID, true
+	case update.PreCheckoutQuery != nil:
+		return update.PreCheckoutQuery.From.ID, true
+	case update.MyChatMember != nil:
+		return update.MyChatMember.Chat.ID, true
+	case update.ChatMember != nil:
+		return update.ChatMember.Chat.ID, true
+	case update.ChatJoinRequest != nil:
+		return update.ChatJoinRequest.Chat.ID, true
+	}
+	return 0, false
+}
+
+// poll receives the updates using long polling and dispatches them until ctx
+// is done, cancelling the pending request. The updates received while the bot
+// was not running will be handled, unless Config.DropPendingUpdates
+func (d *dispatcher) poll(ctx context.Context) error {
+	var client = d.robot.client
+
+	drop := url.Values{"drop_pending_updates": {strconv.FormatBool(d.robot.config.DropPendingUpdates)}}
+	if err := client.CallContext(ctx, "deleteWebhook", drop, nil); err != nil {
+		return err
+	}
+
+	var (
+		params = url.Values{"timeout": {"120"}}
+		offset int
+	)
+	for {
+		var updates []*echotron.Update
+
+		params.Set("offset", strconv.Itoa(offset))
+		err := client.CallContext(ctx, "getUpdates", params, &updates)
+		if ctx.Err() != nil {
+			// Confirm the dispatched updates, so that they will not be received again
+			if offset > 0 {
+				client.Call("getUpdates", url.Values{"offset": {strconv.Itoa(offset)}, "limit": {"1"}}, nil)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, update := range updates {
+			d.dispatch(update)
+			offset = update.ID + 1
+		}
+	}
+}
+
+// decodeUpdate reads an update encoded in JSON
+func decodeUpdate(r io.Reader) (*echotron.Update, error) {
+	var update = new(echotron.Update)
+	if err := json.NewDecoder(r).Decode(update); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// shutdown waits for the running handlers to finish and saves the session data
+func (d *dispatcher) shutdown() error {
+	err := d.wait(d.robot.config.ShutdownTimeout)
+	if e := d.flush(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
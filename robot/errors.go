@@ -0,0 +1,123 @@
+package robot
+
+import (
+	"github.com/DazFather/parrbot/message"
+)
+
+// HandlerFunc is an alternative to CommandFunc that can also return an error.
+// The error will be passed to Config.OnError, while the message (if not nil)
+// will be sent anyway
+type HandlerFunc func(*Bot, *message.Update) (message.Any, error)
+
+// ErrorHandler is the function called with the errors happened while handling
+// an update: the one returned by the handler (or a *PanicError if it panicked)
+// and the one returned when sending its message. Update is nil if the update
+// could not be cast or if the error happened on a scheduled job
+type ErrorHandler func(bot *Bot, update *message.Update, handlerErr, sendErr error)
+
+// CommandFunc converts the handler into a CommandFunc, so that it can be used
+// everywhere a CommandFunc is expected (ex. Await, WaitFor or middlewares).
+// The returned error will still reach Config.OnError, while middlewares will
+// only see the returned message
+func (fn HandlerFunc) CommandFunc() CommandFunc {
+	return func(bot *Bot, update *message.Update) message.Any {
+		msg, err := fn(bot, update)
+		switch {
+		case err == nil:
+			return msg
+		case bot != nil && update != nil:
+			bot.fail(update, err)
+			return msg
+		}
+		return failure{msg: msg, err: err}
+	}
+}
+
+// fail records the error returned by a handler while handling the update, so
+// that send can report it
+func (b *Bot) fail(update *message.Update, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures == nil {
+		b.failures = make(map[*message.Update]error)
+	}
+	b.failures[update] = err
+}
+
+// failed removes and returns the error recorded by fail for the update, if any
+func (b *Bot) failed(update *message.Update) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	err := b.failures[update]
+	delete(b.failures, update)
+	return err
+}
+
+// failure is the message returned by a HandlerFunc that failed when called
+// outside of a robot (without bot or update), it carries the error together
+// with the optional message to send
+type failure struct {
+	msg message.Any
+	err error
+}
+
+// Send the message (if any) and returns the handler error, or the send error
+// if the message could not be sent (by this method failure is a message.Any)
+func (f failure) Send(chatID int64) (*message.UpdateMessage, error) {
+	if f.msg == nil {
+		return nil, f.err
+	}
+	if res, err := f.msg.Send(chatID); err != nil {
+		return res, err
+	}
+	return nil, f.err
+}
+
+// send sends the message returned by a handler and reports the handler error
+// (if it was a HandlerFunc) and the send error to Config.OnError. The fields
+// describe the handler in the logs
+func (b *Bot) send(update *message.Update, msg message.Any, fields ...any) {
+	var handlerErr, sendErr error
+
+	if update != nil {
+		handlerErr = b.failed(update)
+	}
+	if f, ok := msg.(failure); ok {
+		msg, handlerErr = f.msg, f.err
+	}
+	if msg != nil {
+		_, sendErr = b.Robot().client.Send(msg, b.ChatID)
+	}
+
+	if handlerErr != nil || sendErr != nil {
+		b.reportError(update, handlerErr, sendErr, fields...)
+	}
+}
+
+// reportError passes the errors to Config.OnError, or logs them along with the
+// given fields if it is not set
+func (b *Bot) reportError(update *message.Update, handlerErr, sendErr error, fields ...any) {
+	if onError := b.config().OnError; onError != nil {
+		onError(b, update, handlerErr, sendErr)
+		return
+	}
+
+	fields = append(b.logFields(update), fields...)
+	if handlerErr != nil {
+		b.Logger().Error("Handler error", append(fields, "error", handlerErr)...)
+	}
+	if sendErr != nil {
+		b.Logger().Error("Send error", append(fields, "error", sendErr)...)
+	}
+}
+
+// logFields returns the fields that identify the chat and the update in the logs
+func (b *Bot) logFields(update *message.Update) []any {
+	fields := []any{"chat_id", b.ChatID}
+	if update != nil {
+		fields = append(fields, "update_id", update.ID)
+	}
+	return fields
+}
@@ -0,0 +1,128 @@
+package robot_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+)
+
+func ExampleHandlerFunc() {
+	var handler robot.HandlerFunc = func(bot *robot.Bot, update *message.Update) (message.Any, error) {
+		return nil, errors.New("something went wrong")
+	}
+
+	msg := handler.CommandFunc()(nil, nil)
+	_, err := msg.Send(0)
+	fmt.Println(err)
+	// Output:
+	// something went wrong
+}
+
+// reportedErrors records the errors passed to Config.OnError
+type reportedErrors struct {
+	handler, send error
+	calls         int
+}
+
+// startReporting starts a parrbottest.Driver whose errors are recorded on rep
+func startReporting(t *testing.T, rep *reportedErrors, middlewares []robot.Middleware, commandList ...robot.Command) *parrbottest.Driver {
+	t.Helper()
+
+	config := robot.DefaultConfig()
+	config.Middlewares = middlewares
+	config.OnError = func(bot *robot.Bot, update *message.Update, handlerErr, sendErr error) {
+		rep.handler, rep.send = handlerErr, sendErr
+		rep.calls++
+	}
+
+	d, err := parrbottest.Start(config, commandList...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+func TestOnErrorHandler(t *testing.T) {
+	var (
+		rep    reportedErrors
+		failed = errors.New("something went wrong")
+		seen   []message.Any
+	)
+	spy := func(next robot.CommandFunc) robot.CommandFunc {
+		return func(bot *robot.Bot, update *message.Update) message.Any {
+			msg := next(bot, update)
+			seen = append(seen, msg)
+			return msg
+		}
+	}
+
+	d := startReporting(t, &rep, []robot.Middleware{spy},
+		robot.Command{
+			Trigger: "/fail",
+			ReplyAt: message.MESSAGE,
+			Handler: func(*robot.Bot, *message.Update) (message.Any, error) {
+				return nil, failed
+			},
+		},
+		robot.Command{
+			Trigger: "/partial",
+			ReplyAt: message.MESSAGE,
+			Handler: func(*robot.Bot, *message.Update) (message.Any, error) {
+				return message.Text{Text: "partial result"}, failed
+			},
+		},
+		robot.Command{Trigger: "/ping", ReplyAt: message.MESSAGE, CallFunc: answer("pong")},
+	)
+
+	if _, err := d.SendMessage(42, "/fail"); err != nil {
+		t.Fatal(err)
+	}
+	if rep.calls != 1 || rep.handler != failed || rep.send != nil {
+		t.Errorf("OnError got %d calls, handler error %v, send error %v, want 1, %v, nil", rep.calls, rep.handler, rep.send, failed)
+	}
+	if len(seen) != 1 || seen[0] != nil {
+		t.Errorf("middleware saw %#v, want a nil message", seen)
+	}
+
+	// The message is sent anyway
+	expectReply(t, d, 42, "/partial", "partial result")
+	if rep.calls != 2 || rep.handler != failed {
+		t.Errorf("OnError got %d calls, handler error %v, want 2, %v", rep.calls, rep.handler, failed)
+	}
+	if len(seen) != 2 || seen[1] != (message.Text{Text: "partial result"}) {
+		t.Errorf("middleware saw %#v, want the partial result", seen)
+	}
+
+	// Successful handlers are not reported
+	expectReply(t, d, 42, "/ping", "pong")
+	if rep.calls != 2 {
+		t.Errorf("OnError called %d times, want 2", rep.calls)
+	}
+}
+
+func TestOnErrorSend(t *testing.T) {
+	var rep reportedErrors
+	d := startReporting(t, &rep, nil,
+		robot.Command{Trigger: "/ping", ReplyAt: message.MESSAGE, CallFunc: answer("pong")},
+	)
+	d.Handle("sendMessage", func(parrbottest.Call) (interface{}, error) {
+		return nil, &parrbottest.Error{Code: 403, Description: "Forbidden: bot was blocked by the user"}
+	})
+
+	if _, err := d.SendMessage(42, "/ping"); err != nil {
+		t.Fatal(err)
+	}
+	if rep.calls != 1 || rep.handler != nil || rep.send == nil {
+		t.Fatalf("OnError got %d calls, handler error %v, send error %v, want 1, nil, the send error", rep.calls, rep.handler, rep.send)
+	}
+
+	var apiErr *message.ResponseError
+	if !errors.As(rep.send, &apiErr) || apiErr.ErrorCode != 403 {
+		t.Errorf("send error = %v, want the 403 of Telegram", rep.send)
+	}
+}
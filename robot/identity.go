@@ -0,0 +1,38 @@
+package robot
+
+import (
+	"strings"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// loadIdentity retrieves the Telegram user of the bot
+func (r *Robot) loadIdentity() error {
+	var me echotron.User
+	if err := r.client.Call("getMe", nil, &me); err != nil {
+		return err
+	}
+	r.me = &me
+	return nil
+}
+
+// Username returns the username of the bot (without the '@'), or an empty string
+// if not yet known. It will be retrieved from Telegram during Start
+func Username() string {
+	return std.Username()
+}
+
+// Username returns the username of the robot (without the '@'), or an empty
+// string if not yet known. It will be retrieved from Telegram during Run
+func (r *Robot) Username() string {
+	if r.me == nil {
+		return ""
+	}
+	return r.me.Username
+}
+
+// isMe returns true if the given username is the one of the bot. If it's not
+// known yet all usernames will be accepted
+func (r *Robot) isMe(username string) bool {
+	return r.me == nil || strings.EqualFold(r.me.Username, username)
+}
@@ -0,0 +1,42 @@
+package robot_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// printLogger prints the errors with their fields, as a *slog.Logger would do
+type printLogger struct{}
+
+func (printLogger) Debug(msg string, args ...any) {}
+func (printLogger) Info(msg string, args ...any)  {}
+func (printLogger) Warn(msg string, args ...any)  {}
+func (printLogger) Error(msg string, args ...any) { fmt.Println(msg, args) }
+
+func Example_logger() {
+	config := robot.DefaultConfig()
+	config.Logger = printLogger{} // ex. slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	d, err := parrbottest.Start(config, robot.Command{
+		Trigger: "/fail",
+		ReplyAt: message.MESSAGE,
+		Handler: func(*robot.Bot, *message.Update) (message.Any, error) {
+			return nil, errors.New("something went wrong")
+		},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer d.Close()
+
+	d.Update(&echotron.Update{ID: 7, Message: &echotron.Message{Text: "/fail", Chat: echotron.Chat{ID: 42, Type: "private"}}})
+	// Output:
+	// Handler error [chat_id 42 update_id 7 trigger /fail error something went wrong]
+}
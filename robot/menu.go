@@ -0,0 +1,248 @@
+package robot
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/DazFather/parrbot/message"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// These are the most common scopes that can be used on the Scopes field of a Command
+var (
+	// PrivateChatsScope makes the command appear on the menu of all private chats
+	PrivateChatsScope = echotron.BotCommandScope{Type: echotron.BCSTAllPrivateChats}
+	// GroupChatsScope makes the command appear on the menu of all group and supergroup chats
+	GroupChatsScope = echotron.BotCommandScope{Type: echotron.BCSTAllGroupChats}
+	// GroupAdminsScope makes the command appear on the menu of all group and supergroup administrators
+	GroupAdminsScope = echotron.BotCommandScope{Type: echotron.BCSTAllChatAdministrators}
+)
+
+// ChatScope makes the command appear on the menu of a specific chat
+func ChatScope(chatID int64) echotron.BotCommandScope {
+	return echotron.BotCommandScope{Type: echotron.BCSTChat, ChatID: chatID}
+}
+
+// ChatAdminsScope makes the command appear on the menu of the administrators of a specific chat
+func ChatAdminsScope(chatID int64) echotron.BotCommandScope {
+	return echotron.BotCommandScope{Type: echotron.BCSTChatAdministrators, ChatID: chatID}
+}
+
+// ChatMemberScope makes the command appear on the menu of a specific member of a specific chat
+func ChatMemberScope(chatID, userID int64) echotron.BotCommandScope {
+	return echotron.BotCommandScope{Type: echotron.BCSTChatMember, ChatID: chatID, UserID: userID}
+}
+
+// defaultScope is the scope used by the commands that don't specify any
+var defaultScope = echotron.BotCommandScope{Type: echotron.BCSTDefault}
+
+// menuKey identifies a "/" menu: the scope and the language of the users that will see it
+type menuKey struct {
+	scope echotron.BotCommandScope
+	lang  string
+}
+
+// Visible returns true if the command will be displayed on the "/" menu: it
+// needs a Trigger, a Description (or a translation) and to reply at MESSAGE
+func (cmd Command) Visible() bool {
+	return cmd.ReplyAt&message.MESSAGE != 0 && cmd.Trigger != "" && !isFallback(cmd.Trigger) &&
+		(cmd.Description != "" || len(cmd.Descriptions) > 0)
+}
+
+// VisibleTo returns true if the command is Visible and can be used by the sender
+// of the update on the chat where it has been generated: the chat must be one of
+// its Chats and Scopes, and the sender must have one of its Roles. Useful to list
+// only the commands available to the user (ex. tgui.HelpCommand)
+func (cmd Command) VisibleTo(bot *Bot, update *message.Update) bool {
+	if !cmd.Visible() {
+		return false
+	}
+	if chat := update.ChatType(); cmd.Chats != 0 && chat != 0 && cmd.Chats&chat == 0 {
+		return false
+	}
+	if cmd.Roles != 0 && !hasRole(bot, update, cmd.Roles) {
+		return false
+	}
+
+	for _, scope := range scopesOf(cmd) {
+		if inScope(bot, update, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// inScope returns true if the menu of the scope is shown to the sender of the
+// update on the chat where it has been generated
+func inScope(bot *Bot, update *message.Update, scope echotron.BotCommandScope) bool {
+	var (
+		chat  = update.ChatType()
+		group = chat&(message.GROUP_CHAT|message.SUPERGROUP_CHAT) != 0
+	)
+
+	switch scope.Type {
+	case echotron.BCSTDefault:
+		return true
+	case echotron.BCSTAllPrivateChats:
+		return chat == message.PRIVATE_CHAT
+	case echotron.BCSTAllGroupChats:
+		return group
+	case echotron.BCSTAllChatAdministrators:
+		return group && isSenderChatAdmin(bot, update)
+	case echotron.BCSTChat:
+		return scope.ChatID == bot.ChatID
+	case echotron.BCSTChatAdministrators:
+		return scope.ChatID == bot.ChatID && isSenderChatAdmin(bot, update)
+	case echotron.BCSTChatMember:
+		sender := update.Sender()
+		return scope.ChatID == bot.ChatID && sender != nil && sender.ID == scope.UserID
+	}
+	return false
+}
+
+// menuTrigger returns the trigger shown on the "/" menu for the command. As
+// Telegram accepts only lower case commands, it's lowered if it ignores the case
+func menuTrigger(cmd Command) string {
+	if cmd.IgnoreCase {
+		return strings.ToLower(cmd.Trigger)
+	}
+	return cmd.Trigger
+}
+
+// scopesOf returns the scopes of the given command or the default one if none
+func scopesOf(cmd Command) []echotron.BotCommandScope {
+	if len(cmd.Scopes) == 0 {
+		return []echotron.BotCommandScope{defaultScope}
+	}
+	return cmd.Scopes
+}
+
+// covers returns true if the commands of the broad scope should be shown also
+// on the narrow one. Telegram shows only the commands of the narrowest scope
+// (and language) that has any, so they need to be repeated there
+func covers(broad, narrow echotron.BotCommandScope) bool {
+	switch broad.Type {
+	case echotron.BCSTDefault:
+		return true
+	case echotron.BCSTAllPrivateChats:
+		return narrow.Type == echotron.BCSTChat && narrow.ChatID > 0
+	case echotron.BCSTAllGroupChats:
+		return narrow.Type == echotron.BCSTAllChatAdministrators || narrow.ChatID < 0
+	case echotron.BCSTAllChatAdministrators:
+		return narrow.Type == echotron.BCSTChatAdministrators
+	case echotron.BCSTChat:
+		return narrow.ChatID == broad.ChatID &&
+			(narrow.Type == echotron.BCSTChatAdministrators || narrow.Type == echotron.BCSTChatMember)
+	}
+	return false
+}
+
+// shownOn returns true if the command will be displayed on the menu of the given scope
+func shownOn(cmd Command, scope echotron.BotCommandScope) bool {
+	for _, s := range scopesOf(cmd) {
+		if s == scope || covers(s, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildMenus groups the commands of the list into menus, one for each combination
+// of scope and language. Each menu contains also the commands of the broader scopes
+// and commands without a description for a language will use the default one.
+// Returned keys are in order of appearance of the scopes
+func buildMenus(commandList []Command) (keys []menuKey, menus map[menuKey][]echotron.BotCommand) {
+	var (
+		scopes []echotron.BotCommandScope
+		seen   = make(map[echotron.BotCommandScope]bool)
+	)
+	for _, cmd := range commandList {
+		if !cmd.Visible() {
+			continue
+		}
+		for _, scope := range scopesOf(cmd) {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	menus = make(map[menuKey][]echotron.BotCommand)
+	for _, scope := range scopes {
+		var (
+			shown []Command
+			langs = map[string]bool{"": true}
+		)
+		for _, cmd := range commandList {
+			if cmd.Visible() && shownOn(cmd, scope) {
+				shown = append(shown, cmd)
+				for lang := range cmd.Descriptions {
+					langs[lang] = true
+				}
+			}
+		}
+
+		for _, lang := range sortedLanguages(langs) {
+			key := menuKey{scope, lang}
+			for _, cmd := range shown {
+				description, ok := cmd.Descriptions[lang]
+				if !ok || description == "" {
+					description = cmd.Description
+				}
+				if description != "" {
+					menus[key] = append(menus[key], echotron.BotCommand{Command: menuTrigger(cmd), Description: description})
+				}
+			}
+			if len(menus[key]) > 0 {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	return
+}
+
+// sortedLanguages returns the given languages in order, the default one ("") first
+func sortedLanguages(langs map[string]bool) []string {
+	var sorted = make([]string, 0, len(langs))
+	for lang := range langs {
+		sorted = append(sorted, lang)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// setMyCommands registers the given commands as the "/" menu for the scope and language of key.
+// echotron serializes the scope without the JSON names required by Telegram (and doesn't
+// escape the commands), so the request is made using Client.Call
+func (r *Robot) setMyCommands(key menuKey, commands []echotron.BotCommand) error {
+	var params = make(url.Values)
+
+	jsn, err := json.Marshal(commands)
+	if err != nil {
+		return err
+	}
+	params.Set("commands", string(jsn))
+
+	if key.scope != defaultScope {
+		jsn, err = json.Marshal(struct {
+			Type   echotron.BotCommandScopeType `json:"type"`
+			ChatID int64                        `json:"chat_id,omitempty"`
+			UserID int64                        `json:"user_id,omitempty"`
+		}{key.scope.Type, key.scope.ChatID, key.scope.UserID})
+		if err != nil {
+			return err
+		}
+		params.Set("scope", string(jsn))
+	}
+
+	if key.lang != "" {
+		params.Set("language_code", key.lang)
+	}
+
+	return r.client.Call("setMyCommands", params, nil)
+}
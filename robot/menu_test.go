@@ -0,0 +1,121 @@
+package robot_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// registeredMenus loads the commands on a new robot and returns the menus sent to
+// the fake Bot API by scope type and language, as "trigger description" lines
+func registeredMenus(t *testing.T, commandList ...robot.Command) map[string][]string {
+	t.Helper()
+
+	server := parrbottest.NewServer()
+	defer server.Close()
+
+	r, err := server.NewRobot(robot.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.LoadCommands(commandList); err != nil {
+		t.Fatal(err)
+	}
+
+	menus := make(map[string][]string)
+	for _, call := range server.CallsTo("setMyCommands") {
+		var (
+			scope    = struct{ Type string }{"default"}
+			commands []echotron.BotCommand
+		)
+		if call.Params.Has("scope") {
+			if err := call.Decode("scope", &scope); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := call.Decode("commands", &commands); err != nil {
+			t.Fatal(err)
+		}
+
+		key := strings.TrimSpace(scope.Type + " " + call.Params.Get("language_code"))
+		for _, cmd := range commands {
+			menus[key] = append(menus[key], cmd.Command+" "+cmd.Description)
+		}
+	}
+	return menus
+}
+
+func TestMenus(t *testing.T) {
+	menus := registeredMenus(t,
+		robot.Command{
+			Trigger:      "/start",
+			Description:  "Start & stop",
+			Descriptions: map[string]string{"it": "Avvia & ferma"},
+			ReplyAt:      message.MESSAGE,
+		},
+		robot.Command{Trigger: "/profile", Description: "Your profile", ReplyAt: message.MESSAGE, Scopes: []echotron.BotCommandScope{robot.PrivateChatsScope}},
+		robot.Command{Trigger: "/poll", Description: "Create a poll", ReplyAt: message.MESSAGE, Scopes: []echotron.BotCommandScope{robot.GroupChatsScope}},
+		robot.Command{
+			Trigger:      "/ban",
+			Description:  "Ban a user",
+			Descriptions: map[string]string{"it": ""},
+			ReplyAt:      message.MESSAGE,
+			Scopes:       []echotron.BotCommandScope{robot.GroupAdminsScope},
+		},
+		robot.Command{Trigger: "/hidden", ReplyAt: message.MESSAGE},
+		robot.Command{Trigger: "/callback", Description: "Not a message", ReplyAt: message.CALLBACK_QUERY},
+	)
+
+	want := map[string][]string{
+		"default":                    {"/start Start & stop"},
+		"default it":                 {"/start Avvia & ferma"},
+		"all_private_chats":          {"/start Start & stop", "/profile Your profile"},
+		"all_private_chats it":       {"/start Avvia & ferma", "/profile Your profile"},
+		"all_group_chats":            {"/start Start & stop", "/poll Create a poll"},
+		"all_group_chats it":         {"/start Avvia & ferma", "/poll Create a poll"},
+		"all_chat_administrators":    {"/start Start & stop", "/poll Create a poll", "/ban Ban a user"},
+		"all_chat_administrators it": {"/start Avvia & ferma", "/poll Create a poll", "/ban Ban a user"},
+	}
+	if !reflect.DeepEqual(menus, want) {
+		t.Errorf("menus = %v\nwant %v", menus, want)
+	}
+}
+
+func TestMenusChatScope(t *testing.T) {
+	menus := registeredMenus(t,
+		robot.Command{Trigger: "/help", Description: "Help", ReplyAt: message.MESSAGE, Scopes: []echotron.BotCommandScope{robot.PrivateChatsScope}},
+		robot.Command{Trigger: "/admin", Description: "Admin panel", ReplyAt: message.MESSAGE, Scopes: []echotron.BotCommandScope{robot.ChatScope(42)}},
+	)
+
+	want := map[string][]string{
+		"all_private_chats": {"/help Help"},
+		"chat":              {"/help Help", "/admin Admin panel"},
+	}
+	if !reflect.DeepEqual(menus, want) {
+		t.Errorf("menus = %v\nwant %v", menus, want)
+	}
+}
+
+func TestMenusError(t *testing.T) {
+	server := parrbottest.NewServer()
+	defer server.Close()
+	server.Handle("setMyCommands", func(parrbottest.Call) (interface{}, error) {
+		return nil, &parrbottest.Error{Code: 400, Description: "Bad Request: invalid command"}
+	})
+
+	r, err := server.NewRobot(robot.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.LoadCommands([]robot.Command{{Trigger: "/start", Description: "Start", ReplyAt: message.MESSAGE}})
+	if err == nil || !strings.Contains(err.Error(), "invalid command") {
+		t.Errorf("LoadCommands error = %v, want the Telegram one", err)
+	}
+}
@@ -0,0 +1,260 @@
+package robot
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DazFather/parrbot/message"
+)
+
+// These are the values of the "trigger" label of the updates that have not been
+// handled by a command with a Trigger (or a Pattern)
+const (
+	StepMetric    = "<step>"    // handled by a step, see Await
+	DefaultMetric = "<default>" // handled by the command with DefaultTrigger
+	NoneMetric    = "<none>"    // not handled
+)
+
+// metricsBuckets are the upper bounds (in seconds) of the buckets of the histograms
+var metricsBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// updateTypeNames are the values of the "type" label of the updates
+var updateTypeNames = map[message.UpdateType]string{
+	message.MESSAGE:              "message",
+	message.EDITED_MESSAGE:       "edited_message",
+	message.CHANNEL_POST:         "channel_post",
+	message.EDITED_CHANNEL_POST:  "edited_channel_post",
+	message.INLINE_QUERY:         "inline_query",
+	message.CHOSEN_INLINE_RESULT: "chosen_inline_result",
+	message.CALLBACK_QUERY:       "callback_query",
+	message.SHIPPING_QUERY:       "shipping_query",
+	message.PRE_CHECKOUT_QUERY:   "pre_checkout_query",
+	message.MY_CHAT_MEMBER:       "my_chat_member",
+	message.CHAT_MEMBER:          "chat_member",
+	message.CHAT_JOIN_REQUEST:    "chat_join_request",
+}
+
+// Metrics counts the updates received by the robot by type and trigger, measures
+// the latency of the handlers and the requests made to Telegram by method and
+// error code. Use it on the Metrics field of the configuration: they will be
+// served in the Prometheus text format on http://<Listen>/metrics during Run.
+// Metrics is also an http.Handler, to serve them on your own server
+type Metrics struct {
+	Listen string // local address of the metrics endpoint, ex. "localhost:9100". Empty to not serve it
+
+	mu       sync.Mutex
+	updates  map[[2]string]uint64  // number of updates by type and trigger
+	handlers map[string]*histogram // handlers latency by trigger
+	calls    map[[2]string]uint64  // number of requests by method and code
+	latency  map[string]*histogram // requests latency by method
+}
+
+// NewMetrics creates empty metrics that will be served at the given address,
+// same as &Metrics{Listen: listen}
+func NewMetrics(listen string) *Metrics {
+	return &Metrics{Listen: listen}
+}
+
+// alloc allocates the maps of the metrics if needed, mu must be locked
+func (m *Metrics) alloc() {
+	if m.updates == nil {
+		m.updates = make(map[[2]string]uint64)
+		m.handlers = make(map[string]*histogram)
+		m.calls = make(map[[2]string]uint64)
+		m.latency = make(map[string]*histogram)
+	}
+}
+
+// histogram counts the observations by bucket, see metricsBuckets
+type histogram struct {
+	buckets []uint64 // cumulative count of the observations by bucket
+	count   uint64
+	sum     float64
+}
+
+// observe adds a value to the histogram
+func (h *histogram) observe(value float64) {
+	if h.buckets == nil {
+		h.buckets = make([]uint64, len(metricsBuckets))
+	}
+	for i, bound := range metricsBuckets {
+		if value <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// observeUpdate counts an update of the given type, that has been handled by
+// the command with the given trigger (if handled) in the given time
+func (m *Metrics) observeUpdate(updateType message.UpdateType, trigger string, handled bool, elapsed time.Duration) {
+	name, ok := updateTypeNames[updateType]
+	if !ok {
+		name = "other"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.alloc()
+	m.updates[[2]string{name, trigger}]++
+	if !handled {
+		return
+	}
+	if m.handlers[trigger] == nil {
+		m.handlers[trigger] = new(histogram)
+	}
+	m.handlers[trigger].observe(elapsed.Seconds())
+}
+
+// observeCall counts a request made to Telegram, it's a message.CallObserver.
+// Long polling requests are ignored, as they last until an update arrives
+func (m *Metrics) observeCall(method string, elapsed time.Duration, err error) {
+	if method == "getUpdates" {
+		return
+	}
+
+	code := "200"
+	if err != nil {
+		var resErr *message.ResponseError
+		if errors.As(err, &resErr) && resErr.From == "Telegram" {
+			code = strconv.Itoa(resErr.ErrorCode)
+		} else {
+			code = "error"
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.alloc()
+	m.calls[[2]string{method, code}]++
+	if m.latency[method] == nil {
+		m.latency[method] = new(histogram)
+	}
+	m.latency[method].observe(elapsed.Seconds())
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var (
+		buf = bufio.NewWriter(w)
+		cw  = &countWriter{w: buf}
+	)
+
+	m.mu.Lock()
+	writeHeader(cw, "parrbot_updates_total", "counter", "Number of updates received by type and trigger of the command that handled them.")
+	for _, key := range sortedPairs(m.updates) {
+		fmt.Fprintf(cw, "parrbot_updates_total{type=%s,trigger=%s} %d\n", quote(key[0]), quote(key[1]), m.updates[key])
+	}
+	writeHeader(cw, "parrbot_handler_duration_seconds", "histogram", "Time spent by the handlers by trigger of the command.")
+	for _, trigger := range sortedNames(m.handlers) {
+		writeHistogram(cw, "parrbot_handler_duration_seconds", "trigger="+quote(trigger), m.handlers[trigger])
+	}
+	writeHeader(cw, "parrbot_api_calls_total", "counter", "Number of requests made to the Telegram Bot API by method and response code.")
+	for _, key := range sortedPairs(m.calls) {
+		fmt.Fprintf(cw, "parrbot_api_calls_total{method=%s,code=%s} %d\n", quote(key[0]), quote(key[1]), m.calls[key])
+	}
+	writeHeader(cw, "parrbot_api_call_duration_seconds", "histogram", "Time spent by the requests made to the Telegram Bot API by method.")
+	for _, method := range sortedNames(m.latency) {
+		writeHistogram(cw, "parrbot_api_call_duration_seconds", "method="+quote(method), m.latency[method])
+	}
+	m.mu.Unlock()
+
+	if err := buf.Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, cw.err
+}
+
+// serve serves the metrics at /metrics on the Listen address, until stop is called
+func (m *Metrics) serve() (stop func(), err error) {
+	listener, err := net.Listen("tcp", m.Listen)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+
+	return func() { server.Close() }, nil
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeHistogram writes the lines of a histogram with the given labels
+func writeHistogram(w io.Writer, name, labels string, h *histogram) {
+	for i, bound := range metricsBuckets {
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(bound, 'g', -1, 64), h.buckets[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+// quote returns the label value between quotes, escaped as required by the
+// Prometheus text format
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+// sortedPairs returns the keys of the counters in order
+func sortedPairs(counters map[[2]string]uint64) [][2]string {
+	keys := make([][2]string, 0, len(counters))
+	for key := range counters {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1]
+	})
+	return keys
+}
+
+// sortedNames returns the keys of the histograms in order
+func sortedNames(histograms map[string]*histogram) []string {
+	keys := make([]string, 0, len(histograms))
+	for key := range histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// countWriter counts the written bytes and keeps the first error
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+// Write implements io.Writer
+func (cw *countWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
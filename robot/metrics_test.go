@@ -0,0 +1,79 @@
+package robot_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+)
+
+func ExampleMetrics() {
+	config := robot.DefaultConfig()
+	config.Metrics = robot.NewMetrics("") // ex. "localhost:9100" to serve them on /metrics
+
+	d, err := parrbottest.Start(config, robot.Command{
+		Trigger: "/start",
+		ReplyAt: message.MESSAGE,
+		CallFunc: func(*robot.Bot, *message.Update) message.Any {
+			return message.Text{Text: "Hello"}
+		},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	d.SendMessage(42, "/start")
+	d.SendMessage(42, "hi")
+	d.Close()
+
+	var buf bytes.Buffer
+	config.Metrics.WriteTo(&buf)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "parrbot_updates_total") || strings.HasPrefix(line, "parrbot_api_calls_total") {
+			fmt.Println(line)
+		}
+	}
+	// Output:
+	// parrbot_updates_total{type="message",trigger="/start"} 1
+	// parrbot_updates_total{type="message",trigger="<none>"} 1
+	// parrbot_api_calls_total{method="deleteWebhook",code="200"} 1
+	// parrbot_api_calls_total{method="getMe",code="200"} 1
+	// parrbot_api_calls_total{method="sendMessage",code="200"} 1
+}
+
+func TestMetricsLiteral(t *testing.T) {
+	var (
+		config  = robot.DefaultConfig()
+		metrics = &robot.Metrics{}
+		logger  = new(errorLogger)
+	)
+	config.Metrics = metrics
+	config.Logger = logger
+
+	d, err := parrbottest.Start(config, robot.Command{Trigger: "/ping", ReplyAt: message.MESSAGE, CallFunc: answer("pong")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectReply(t, d, 42, "/ping", "pong")
+	if err = d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logger.errors) > 0 {
+		t.Errorf("errors logged: %v", logger.errors)
+	}
+	var buf bytes.Buffer
+	metrics.WriteTo(&buf)
+	for _, want := range []string{
+		`parrbot_updates_total{type="message",trigger="/ping"} 1`,
+		`parrbot_api_calls_total{method="sendMessage",code="200"} 1`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("metrics don't contain %s:\n%s", want, buf.String())
+		}
+	}
+}
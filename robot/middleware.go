@@ -0,0 +1,17 @@
+package robot
+
+// Middleware is a function that wraps a CommandFunc in order to run some logic
+// before and / or after it (ex. logging, auth checks, timing...). It can also
+// decide to not call the wrapped function at all
+type Middleware func(next CommandFunc) CommandFunc
+
+// Chain wraps the given CommandFunc with all the given middlewares. The first
+// middleware will be the outermost, so the first to be executed
+func Chain(fn CommandFunc, middlewares ...Middleware) CommandFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if middlewares[i] != nil {
+			fn = middlewares[i](fn)
+		}
+	}
+	return fn
+}
@@ -0,0 +1,33 @@
+package robot_test
+
+import (
+	"fmt"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/robot"
+)
+
+func ExampleChain() {
+	var logger = func(name string) robot.Middleware {
+		return func(next robot.CommandFunc) robot.CommandFunc {
+			return func(bot *robot.Bot, update *message.Update) message.Any {
+				fmt.Println("before", name)
+				defer fmt.Println("after", name)
+				return next(bot, update)
+			}
+		}
+	}
+
+	handler := robot.Chain(func(*robot.Bot, *message.Update) message.Any {
+		fmt.Println("handler")
+		return nil
+	}, logger("first"), logger("second"))
+
+	handler(nil, nil)
+	// Output:
+	// before first
+	// before second
+	// handler
+	// after second
+	// after first
+}
@@ -0,0 +1,58 @@
+package robot
+
+import (
+	"regexp"
+	"strings"
+)
+
+// PathPattern compiles a path-like pattern into a regular expression that can be
+// used as Pattern of a Command. Every word starting with ':' is a parameter that
+// will match a single word and will be available using update.Param, ex:
+// "/item :id" will match "/item 42" with id = "42", "/item_:id" will match "/item_42"
+func PathPattern(path string) *regexp.Regexp {
+	var (
+		param = regexp.MustCompile(`:(\w+)`)
+		expr  strings.Builder
+		last  int
+	)
+
+	quote := func(literal string) string {
+		return regexp.MustCompile(`\s+`).ReplaceAllString(regexp.QuoteMeta(literal), `\s+`)
+	}
+
+	expr.WriteString("^")
+	for _, loc := range param.FindAllStringSubmatchIndex(path, -1) {
+		expr.WriteString(quote(path[last:loc[0]]))
+		expr.WriteString(`(?P<` + path[loc[2]:loc[3]] + `>\S+?)`)
+		last = loc[1]
+	}
+	expr.WriteString(quote(path[last:]))
+	expr.WriteString(`(?:\s|$)`)
+
+	return regexp.MustCompile(expr.String())
+}
+
+// matchPattern returns the first command whose pattern match the text of the
+// given target, and the values of the named groups of the pattern
+func (r *Robot) matchPattern(t target) (entry, map[string]string) {
+	for _, cmd := range r.commands.patterns[t.filter] {
+		if !cmd.accepts(t.chat) {
+			continue
+		}
+
+		match := cmd.pattern.FindStringSubmatch(t.text)
+		if match == nil {
+			continue
+		}
+
+		params := make(map[string]string)
+		for i, name := range cmd.pattern.SubexpNames() {
+			if name != "" && i < len(match) {
+				params[name] = match[i]
+			}
+		}
+		return cmd, params
+	}
+
+	return entry{}, nil
+}
@@ -0,0 +1,52 @@
+package robot_test
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/robot"
+)
+
+func ExamplePathPattern() {
+	var pattern = robot.PathPattern("/item :id")
+
+	fmt.Println(pattern.MatchString("/item 42"))
+	fmt.Println(pattern.MatchString("/items 42"))
+	// Output:
+	// true
+	// false
+}
+
+func ExampleSelect() {
+	robot.LoadCommands([]robot.Command{
+		{
+			Pattern: robot.PathPattern("/item_:id"),
+			ReplyAt: message.MESSAGE,
+			CallFunc: func(bot *robot.Bot, update *message.Update) message.Any {
+				fmt.Println("item", update.Param("id"))
+				return nil
+			},
+		},
+		{
+			Pattern: regexp.MustCompile(`^vote:(?P<choice>yes|no)$`),
+			ReplyAt: message.CALLBACK_QUERY,
+			CallFunc: func(bot *robot.Bot, update *message.Update) message.Any {
+				fmt.Println("voted", update.Param("choice"))
+				return nil
+			},
+		},
+	})
+
+	var update = &message.Update{Message: &message.UpdateMessage{Text: "/item_42"}}
+	robot.Select(update)(nil, update)
+
+	update = &message.Update{CallbackQuery: &message.CallbackQuery{Data: "vote:yes"}}
+	robot.Select(update)(nil, update)
+
+	fmt.Println(robot.Select(&message.Update{CallbackQuery: &message.CallbackQuery{Data: "vote:maybe"}}) == nil)
+	// Output:
+	// item 42
+	// voted yes
+	// true
+}
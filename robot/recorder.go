@@ -0,0 +1,207 @@
+package robot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DazFather/parrbot/message"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// Record is a line of a recording: an incoming update or an outgoing call
+type Record struct {
+	Time   time.Time        `json:"time"`
+	Update *echotron.Update `json:"update,omitempty"`
+	Call   *RecordedCall    `json:"call,omitempty"`
+}
+
+// RecordedCall is a request made to the Telegram Bot API. The token, the
+// uploaded files and the getUpdates requests are not recorded, while the value
+// of the credentials (ex. the secret_token of setWebhook) is RedactedParam
+type RecordedCall struct {
+	Method string     `json:"method"`
+	Params url.Values `json:"params,omitempty"`
+}
+
+// Recorder writes every incoming update (and the outgoing calls if Calls is true)
+// as JSON lines, to be able to reproduce the same sequence using Replay.
+// Use it on the Recorder field of the configuration
+type Recorder struct {
+	Calls bool // when true, also the requests made to the Telegram Bot API are recorded
+
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewRecorder creates a Recorder that writes on w
+func NewRecorder(w io.Writer) *Recorder {
+	rec := &Recorder{w: w}
+	if closer, ok := w.(io.Closer); ok {
+		rec.closer = closer
+	}
+	return rec
+}
+
+// CreateRecording creates a Recorder that appends to the file at the given path,
+// creating it if missing. Use Close when done
+func CreateRecording(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecorder(file), nil
+}
+
+// Close closes the underlying writer, if it's an io.Closer
+func (rec *Recorder) Close() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if rec.closer == nil {
+		return nil
+	}
+	return rec.closer.Close()
+}
+
+// write writes the record as a new line
+func (rec *Recorder) write(record Record) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	_, err = rec.w.Write(append(raw, '\n'))
+	return err
+}
+
+// update records an incoming update
+func (rec *Recorder) update(update *echotron.Update) error {
+	return rec.write(Record{Time: time.Now(), Update: update})
+}
+
+// call records an outgoing request
+func (rec *Recorder) call(method string, params url.Values) error {
+	return rec.write(Record{Time: time.Now(), Call: &RecordedCall{method, params}})
+}
+
+// ReadRecording reads all the records written by a Recorder
+func ReadRecording(src io.Reader) (records []Record, err error) {
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var record Record
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("Recording line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// Replay passes the recorded updates, in order and without waiting the original
+// timings, to the handlers of the running robot, each one after the previous
+// handler returned. The recorded calls are ignored. To avoid contacting Telegram
+// run the robot on a fake API, see parrbottest
+func (r *Robot) Replay(src io.Reader) error {
+	records, err := ReadRecording(src)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if record.Update == nil {
+			continue
+		}
+		if err = r.Serve(record.Update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RedactedParam replaces the value of the credentials in the recorded calls
+const RedactedParam = "REDACTED"
+
+// secretParams are the parameters of the Bot API methods that contain credentials
+var secretParams = []string{"secret_token", "provider_token"}
+
+// recordCalls makes rec record the requests made to the Bot API by the client,
+// wrapping its transport until the returned function is called
+func recordCalls(rec *Recorder, client *message.Client) (stop func()) {
+	previous := client.Transport()
+	client.SetTransport(recordingTransport{rec, previous})
+	return func() {
+		client.SetTransport(previous)
+	}
+}
+
+// recordingTransport passes the requests made to the Bot API to the recorder,
+// before sending them with next (http.DefaultTransport if nil)
+type recordingTransport struct {
+	rec  *Recorder
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := record(t.rec, req); err != nil {
+		return nil, err
+	}
+	if t.next == nil {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// record passes the request to the recorder, without the credentials
+func record(rec *Recorder, req *http.Request) error {
+	_, method, ok := strings.Cut(strings.TrimPrefix(req.URL.Path, "/bot"), "/")
+	if !ok || method == "getUpdates" || strings.HasPrefix(req.URL.Path, "/file/") {
+		return nil
+	}
+
+	params := req.URL.Query()
+	if req.Body != nil && req.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return errors.New("Unable to record " + method + ": " + err.Error())
+		}
+		for key, values := range form {
+			params[key] = append(params[key], values...)
+		}
+	}
+	for _, key := range secretParams {
+		if params.Has(key) {
+			params.Set(key, RedactedParam)
+		}
+	}
+
+	// A recording that can't be written shouldn't stop the bot
+	rec.call(method, params)
+	return nil
+}
@@ -0,0 +1,95 @@
+package robot_test
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+)
+
+func TestRecorderCalls(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		config = robot.DefaultConfig()
+	)
+	config.Recorder = robot.NewRecorder(&buf)
+	config.Recorder.Calls = true
+
+	d, err := parrbottest.Start(config, robot.Command{
+		Trigger: "/webhook",
+		ReplyAt: message.MESSAGE,
+		CallFunc: func(bot *robot.Bot, update *message.Update) message.Any {
+			params := url.Values{"url": {"https://example.com/bot"}, "secret_token": {"s3cr3t"}}
+			if err := update.Client().Call("setWebhook", params, nil); err != nil {
+				return message.Text{Text: err.Error()}
+			}
+			return message.Text{Text: "done"}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectReply(t, d, 42, "/webhook", "done")
+	if err = d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(buf.String(), "s3cr3t") || strings.Contains(buf.String(), d.Token) {
+		t.Fatalf("recording contains a credential:\n%s", buf.String())
+	}
+	records, err := robot.ReadRecording(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := make(map[string]url.Values)
+	for _, record := range records {
+		if record.Call != nil {
+			calls[record.Call.Method] = record.Call.Params
+		}
+	}
+	if params, ok := calls["setWebhook"]; !ok || params.Get("secret_token") != robot.RedactedParam || params.Get("url") != "https://example.com/bot" {
+		t.Errorf("recorded setWebhook params = %v, want the url and the redacted secret", params)
+	}
+	if params, ok := calls["sendMessage"]; !ok || params.Get("text") != "done" {
+		t.Errorf("recorded sendMessage params = %v, want the reply", params)
+	}
+}
+
+func TestRecorderRestoresTransport(t *testing.T) {
+	server := parrbottest.NewServer()
+	defer server.Close()
+
+	config := robot.DefaultConfig()
+	config.Recorder = robot.NewRecorder(new(bytes.Buffer))
+	config.Recorder.Calls = true
+	r, err := server.NewRobot(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Run(ctx)
+	}()
+	if _, ok := server.WaitFor("deleteWebhook", parrbottest.StartTimeout); !ok {
+		t.Fatal("robot not started")
+	}
+	if r.Client().Transport() == server {
+		t.Error("calls are not recorded while running")
+	}
+
+	cancel()
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	if r.Client().Transport() != server {
+		t.Errorf("transport after Run = %v, want the server", r.Client().Transport())
+	}
+}
@@ -0,0 +1,47 @@
+package robot
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/DazFather/parrbot/message"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// PanicError is the error generated when a handler panics while handling an update
+type PanicError struct {
+	Value interface{} `json:"-"`     // The value passed to panic
+	Stack []string    `json:"stack"` // The stack trace of the goroutine when it panicked, line by line
+}
+
+// Error returns the description of the panic (by creating this method PanicError is a error interface)
+func (err PanicError) Error() string {
+	return fmt.Sprint("panic: ", err.Value)
+}
+
+// newPanicError creates the PanicError of the given recovered value, with the
+// stack trace of the current goroutine
+func newPanicError(value interface{}) *PanicError {
+	return &PanicError{
+		Value: value,
+		Stack: strings.Split(strings.TrimSpace(string(debug.Stack())), "\n"),
+	}
+}
+
+// reportPanic logs the given error, along with the given fields, and if
+// Config.DeveloperChatID is set sends it to the developer along with the
+// original update using message.Log. Then it passes the error to Config.OnError, if set
+func (b *Bot) reportPanic(err *PanicError, update *message.Update, u *echotron.Update, fields ...any) {
+	fields = append(b.logFields(update), fields...)
+	b.Logger().Error(err.Error(), append(fields, "stack", strings.Join(err.Stack, "\n"))...)
+
+	var config = b.config()
+	if chatID := config.DeveloperChatID; chatID != 0 {
+		b.Robot().client.Log(chatID, err, u)
+	}
+	if config.OnError != nil {
+		config.OnError(b, update, err, nil)
+	}
+}
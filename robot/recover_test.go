@@ -0,0 +1,81 @@
+package robot_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+)
+
+// errorLogger records the messages of the errors logged
+type errorLogger struct {
+	mu     sync.Mutex
+	errors []string
+}
+
+func (*errorLogger) Debug(msg string, args ...any) {}
+func (*errorLogger) Info(msg string, args ...any)  {}
+func (*errorLogger) Warn(msg string, args ...any)  {}
+func (l *errorLogger) Error(msg string, args ...any) {
+	l.mu.Lock()
+	l.errors = append(l.errors, msg)
+	l.mu.Unlock()
+}
+
+func TestPanicRecovery(t *testing.T) {
+	var (
+		config    = robot.DefaultConfig()
+		logger    = new(errorLogger)
+		developer = int64(1000)
+		reported  error
+	)
+	config.Logger = logger
+	config.DeveloperChatID = developer
+	config.OnError = func(bot *robot.Bot, update *message.Update, handlerErr, sendErr error) {
+		reported = handlerErr
+	}
+
+	d, err := parrbottest.Start(config,
+		robot.Command{
+			Trigger:  "/panic",
+			ReplyAt:  message.MESSAGE,
+			CallFunc: func(*robot.Bot, *message.Update) message.Any { panic("boom") },
+		},
+		robot.Command{Trigger: "/ping", ReplyAt: message.MESSAGE, CallFunc: answer("pong")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	if _, err = d.SendMessage(42, "/panic"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The panic is logged and passed to OnError
+	if len(logger.errors) != 1 || logger.errors[0] != "panic: boom" {
+		t.Errorf("logged errors = %q, want the panic", logger.errors)
+	}
+	var panicErr *robot.PanicError
+	if !errors.As(reported, &panicErr) || panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+		t.Errorf("OnError received %#v, want a *PanicError with the stack", reported)
+	}
+
+	// The stack trace is sent to the developer chat
+	report, ok := d.LastMessage(developer)
+	if !ok {
+		t.Fatal("panic not reported on the developer chat")
+	}
+	for _, want := range []string{"panic: boom", "stack", "recover_test.go", "/panic"} {
+		if !strings.Contains(report.Text, want) {
+			t.Errorf("report doesn't contain %q:\n%s", want, report.Text)
+		}
+	}
+
+	// The bot keeps serving the same chat
+	expectReply(t, d, 42, "/ping", "pong")
+}
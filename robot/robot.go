@@ -0,0 +1,174 @@
+package robot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/DazFather/parrbot/message"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// Robot is a bot with its own configuration, API client, commands, sessions and
+// scheduled jobs. Use New to run more bots on the same program, otherwise the
+// package level functions (ex. Start) will use the default one, configured by Config
+type Robot struct {
+	config   *ParrbotConfig
+	client   *message.Client
+	token    string         // token used by client
+	commands commandTable   // commands of the command list, see Select
+	mu       sync.Mutex     // guards running and dsp
+	running  bool           // true during Run
+	dsp      *dispatcher    // nil when not ready to receive updates
+	me       *echotron.User // Telegram user of the bot, retrieved at Run using getMe
+	sched    *scheduler
+	admins   *adminCache
+}
+
+// std is the default Robot, used by the package level functions
+var std = newRobot(&Config, message.DefaultClient())
+
+func newRobot(config *ParrbotConfig, client *message.Client) *Robot {
+	r := &Robot{config: config, client: client, admins: newAdminCache()}
+	r.sched = newScheduler(r)
+	return r
+}
+
+// New creates a new Robot with a copy of the given configuration, start from
+// DefaultConfig to get the default values. The token must be set using
+// SetAPIToken (or LoadFile) as, unlike the default robot, the environment
+// variables, the flags and the program arguments are not used
+func New(config ParrbotConfig) *Robot {
+	var r = newRobot(&config, nil)
+	r.loadClient()
+	return r
+}
+
+// Config returns the configuration of the robot, edit it before Run
+func (r *Robot) Config() *ParrbotConfig {
+	return r.config
+}
+
+// Client returns the client used by the robot to send the requests to Telegram,
+// nil if the robot has been created with New without a token and Run has not
+// been called yet
+func (r *Robot) Client() *message.Client {
+	return r.client
+}
+
+// Logger returns the logger of the robot, given by the configuration
+func (r *Robot) Logger() message.Logger {
+	if r.config.Logger != nil {
+		return r.config.Logger
+	}
+	return message.StdLogger()
+}
+
+// loadClient creates the client of the robot, if missing or if the token has
+// changed. The default robot always uses message.DefaultClient
+func (r *Robot) loadClient() {
+	if r == std || r.config.token == "" || (r.client != nil && r.token == r.config.token) {
+		return
+	}
+	r.client = message.NewClient(r.config.token)
+	r.token = r.config.token
+}
+
+// Run works as RunContext, but for this robot
+func (r *Robot) Run(ctx context.Context, commandList ...Command) (err error) {
+	// Initialization
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return errors.New("Robot is already running")
+	}
+	r.running = true
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.running, r.dsp = false, nil
+		r.mu.Unlock()
+	}()
+
+	if err = r.config.init(r == std); err != nil {
+		return fmt.Errorf("Config error: %w", err)
+	}
+	r.loadClient()
+	if r.config.Logger != nil {
+		r.client.SetLogger(r.config.Logger)
+	}
+	if rec := r.config.Recorder; rec != nil && rec.Calls {
+		defer recordCalls(rec, r.client)()
+	}
+	if metrics := r.config.Metrics; metrics != nil {
+		r.client.Observe(metrics.observeCall)
+		defer r.client.Observe(nil)
+		if metrics.Listen != "" {
+			stop, err := metrics.serve()
+			if err != nil {
+				return fmt.Errorf("Metrics error: %w", err)
+			}
+			defer stop()
+		}
+	}
+	if err = r.loadIdentity(); err != nil {
+		return fmt.Errorf("GetMe error: %w", err)
+	}
+	if err = r.LoadCommands(commandList); err != nil {
+		return err
+	}
+	dsp := newDispatcher(r)
+	if err = r.sched.start(dsp); err != nil {
+		return fmt.Errorf("Scheduler error: %w", err)
+	}
+	r.setDispatcher(dsp)
+
+	// Put life into the bot
+	if webhook := r.config.Webhook; webhook != nil {
+		err = dsp.listenWebhook(ctx, *webhook)
+	} else {
+		err = dsp.poll(ctx)
+	}
+
+	// Gracefully shutdown
+	r.setDispatcher(nil)
+	r.sched.stop()
+	if e := dsp.shutdown(); e != nil && err == nil {
+		err = e
+	}
+	return
+}
+
+// setDispatcher sets the dispatcher used by Serve, nil when the robot is not
+// ready to receive updates
+func (r *Robot) setDispatcher(dsp *dispatcher) {
+	r.mu.Lock()
+	r.dsp = dsp
+	r.mu.Unlock()
+}
+
+// Serve passes the given update to the running robot as if it was received from
+// Telegram, and waits for its handler to return. Useful to test the bot, see
+// the parrbottest package
+func (r *Robot) Serve(update *echotron.Update) error {
+	r.mu.Lock()
+	dsp := r.dsp
+	if dsp != nil {
+		dsp.inflight.Add(1)
+	}
+	r.mu.Unlock()
+
+	if dsp == nil {
+		return errors.New("Robot is not running")
+	}
+	defer dsp.inflight.Done()
+
+	chatID, ok := chatOf(update)
+	if !ok {
+		return errors.New("Unable to find the chat of the update")
+	}
+	dsp.instance(chatID).Update(update)
+	return nil
+}
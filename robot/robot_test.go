@@ -0,0 +1,151 @@
+package robot_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+func ExampleNew() {
+	greeter := func(greeting string) robot.Command {
+		return robot.Command{
+			Trigger: "/start",
+			ReplyAt: message.MESSAGE,
+			CallFunc: func(*robot.Bot, *message.Update) message.Any {
+				fmt.Println(greeting)
+				return nil
+			},
+		}
+	}
+
+	english, italian := robot.New(robot.DefaultConfig()), robot.New(robot.DefaultConfig())
+	english.LoadCommands([]robot.Command{greeter("Hello")})
+	italian.LoadCommands([]robot.Command{greeter("Ciao")})
+
+	update := &message.Update{Message: &message.UpdateMessage{Text: "/start"}}
+	english.Select(update)(&robot.Bot{}, update)
+	italian.Select(update)(&robot.Bot{}, update)
+	// Output:
+	// Hello
+	// Ciao
+}
+
+// textUpdate returns an update carrying a text message on the given chat
+func textUpdate(chatID int64, text string) *echotron.Update {
+	return &echotron.Update{Message: &echotron.Message{
+		From: &echotron.User{ID: chatID},
+		Chat: echotron.Chat{ID: chatID, Type: "private"},
+		Text: text,
+	}}
+}
+
+func TestRunWaitsHandlers(t *testing.T) {
+	var (
+		started  = make(chan struct{})
+		finished = make(chan struct{})
+	)
+	d, err := parrbottest.Start(robot.DefaultConfig(), robot.Command{
+		Trigger: "/slow",
+		ReplyAt: message.MESSAGE,
+		CallFunc: func(*robot.Bot, *message.Update) message.Any {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+			close(finished)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d.Push(textUpdate(42, "/slow"))
+	<-started
+	if err = d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-finished:
+	default:
+		t.Error("Run returned before the running handler finished")
+	}
+}
+
+func TestRunShutdownTimeout(t *testing.T) {
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+		config  = robot.DefaultConfig()
+	)
+	defer close(release)
+
+	config.ShutdownTimeout = 50 * time.Millisecond
+	d, err := parrbottest.Start(config, robot.Command{
+		Trigger: "/stuck",
+		ReplyAt: message.MESSAGE,
+		CallFunc: func(*robot.Bot, *message.Update) message.Any {
+			close(started)
+			<-release
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d.Push(textUpdate(42, "/stuck"))
+	<-started
+
+	start := time.Now()
+	err = d.Close()
+	if err == nil || !strings.Contains(err.Error(), "Shutdown timeout") {
+		t.Errorf("Close error = %v, want the shutdown timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close took %v, longer than the ShutdownTimeout", elapsed)
+	}
+}
+
+func TestRunPendingUpdates(t *testing.T) {
+	server := parrbottest.NewServer()
+	defer server.Close()
+	server.Push(textUpdate(42, "/start")) // sent while the bot was not running
+
+	var (
+		handled     = make(chan struct{})
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan error, 1)
+	)
+	r, err := server.NewRobot(robot.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		done <- r.Run(ctx, robot.Command{
+			Trigger: "/start",
+			ReplyAt: message.MESSAGE,
+			CallFunc: func(*robot.Bot, *message.Update) message.Any {
+				close(handled)
+				return nil
+			},
+		})
+	}()
+
+	select {
+	case <-handled:
+	case <-time.After(5 * time.Second):
+		t.Error("update sent before the start not handled")
+	}
+	cancel()
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+}
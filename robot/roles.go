@@ -0,0 +1,143 @@
+package robot
+
+import (
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/NicoNex/echotron/v3"
+)
+
+// Role is who is allowed to use a command, used on the "Roles" Command field
+type Role uint8
+
+const (
+	OWNER      Role = 1 << iota // The user with ID Config.OwnerID, that can use any command
+	ADMIN                       // The users with the ID contained on Config.AdminIDs
+	CHAT_ADMIN                  // The administrators (and the creator) of the chat where the command is used
+)
+
+// unauthorizedText is the alert shown by default on the callback queries of unauthorized users
+const unauthorizedText = "⛔️ You are not allowed to do this"
+
+// restrict wraps fn so that it will run only if the sender of the update has
+// at least one of the given roles, otherwise Config.Unauthorized will run instead
+func restrict(fn CommandFunc, roles Role) CommandFunc {
+	if roles == 0 {
+		return fn
+	}
+	return func(bot *Bot, update *message.Update) message.Any {
+		if hasRole(bot, update, roles) {
+			return fn(bot, update)
+		}
+		if unauthorized := bot.config().Unauthorized; unauthorized != nil {
+			return unauthorized(bot, update)
+		}
+		if update.CallbackQuery != nil {
+			update.CallbackQuery.AnswerAlert(unauthorizedText, 0)
+		}
+		return nil
+	}
+}
+
+// hasRole returns true if the sender of the update has at least one of the given roles
+func hasRole(bot *Bot, update *message.Update, roles Role) bool {
+	user := update.Sender()
+	if user == nil {
+		return roles&CHAT_ADMIN != 0 && isSenderChatAdmin(bot, update)
+	}
+
+	var config = bot.config()
+	if config.OwnerID != 0 && user.ID == config.OwnerID {
+		return true
+	}
+	if roles&ADMIN != 0 {
+		for _, id := range config.AdminIDs {
+			if id == user.ID {
+				return true
+			}
+		}
+	}
+	return roles&CHAT_ADMIN != 0 && isSenderChatAdmin(bot, update)
+}
+
+// isSenderChatAdmin returns true if the sender of the update is the creator or
+// an administrator of the chat of the bot
+func isSenderChatAdmin(bot *Bot, update *message.Update) bool {
+	// Anonymous administrators send messages on behalf of the group itself
+	if msg := update.FromMessage(); msg != nil && msg.SenderChat != nil && msg.SenderChat.ID == bot.ChatID {
+		return true
+	}
+
+	user := update.Sender()
+	return user != nil && bot.Robot().isChatAdmin(bot.ChatID, user.ID)
+}
+
+// adminKey identifies a user on a chat
+type adminKey struct {
+	chatID, userID int64
+}
+
+// adminStatus is the cached result of getChatMember
+type adminStatus struct {
+	admin   bool
+	expires time.Time
+}
+
+// adminCache caches whether the users are administrators of the chats
+type adminCache struct {
+	mu      sync.Mutex
+	entries map[adminKey]adminStatus
+}
+
+// newAdminCache creates an empty adminCache
+func newAdminCache() *adminCache {
+	return &adminCache{entries: make(map[adminKey]adminStatus)}
+}
+
+// isChatAdmin returns true if the user is the creator or an administrator of the
+// chat, asking Telegram only when the cached result is missing or expired
+func (r *Robot) isChatAdmin(chatID, userID int64) bool {
+	var (
+		c   = r.admins
+		key = adminKey{chatID, userID}
+	)
+
+	c.mu.Lock()
+	status, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(status.expires) {
+		return status.admin
+	}
+
+	var (
+		member echotron.ChatMember
+		params = url.Values{"chat_id": {strconv.FormatInt(chatID, 10)}, "user_id": {strconv.FormatInt(userID, 10)}}
+	)
+	if err := r.client.Call("getChatMember", params, &member); err != nil {
+		return false
+	}
+	status.admin = member.Status == "creator" || member.Status == "administrator"
+	status.expires = time.Now().Add(r.config.ChatAdminsCacheTTL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clean()
+	c.entries[key] = status
+	return status.admin
+}
+
+// clean removes the expired entries when they are too many, mu must be locked
+func (c *adminCache) clean() {
+	if len(c.entries) < 1000 {
+		return
+	}
+	now := time.Now()
+	for key, status := range c.entries {
+		if now.After(status.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
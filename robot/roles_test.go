@@ -0,0 +1,160 @@
+package robot_test
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+func ExampleRole() {
+	defer func() { robot.Config.OwnerID, robot.Config.AdminIDs, robot.Config.Unauthorized = 0, nil, nil }()
+	robot.Config.OwnerID = 1
+	robot.Config.AdminIDs = []int64{2}
+	robot.Config.Unauthorized = func(*robot.Bot, *message.Update) message.Any {
+		fmt.Println("not allowed")
+		return nil
+	}
+
+	robot.LoadCommands([]robot.Command{{
+		Trigger: "/ban",
+		ReplyAt: message.MESSAGE,
+		Roles:   robot.ADMIN,
+		CallFunc: func(*robot.Bot, *message.Update) message.Any {
+			fmt.Println("banned")
+			return nil
+		},
+	}})
+
+	for _, userID := range []int64{1, 2, 3} {
+		update := &message.Update{Message: &message.UpdateMessage{
+			Text: "/ban",
+			From: &echotron.User{ID: userID},
+		}}
+		robot.Select(update)(&robot.Bot{ChatID: userID}, update)
+	}
+	// Output:
+	// banned
+	// banned
+	// not allowed
+}
+
+func TestUnauthorizedCallback(t *testing.T) {
+	config := robot.DefaultConfig()
+	config.AdminIDs = []int64{7}
+	d, err := parrbottest.Start(config, robot.Command{
+		Trigger:  "/ban",
+		ReplyAt:  message.CALLBACK_QUERY,
+		Roles:    robot.ADMIN,
+		CallFunc: answer("banned"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	msg := d.Store(echotron.Message{Chat: echotron.Chat{ID: 42, Type: "private"}, Text: "Ban?"})
+	if err = d.Update(callback(msg, "/ban")); err != nil {
+		t.Fatal(err)
+	}
+
+	answers := d.CallsTo("answerCallbackQuery")
+	if len(answers) != 1 || answers[0].Text() != "⛔️ You are not allowed to do this" || answers[0].Params.Get("show_alert") != "true" {
+		t.Errorf("answers to the unauthorized callback = %+v, want the default alert", answers)
+	}
+	if sent := d.CallsTo("sendMessage"); len(sent) != 0 {
+		t.Errorf("the command ran for an unauthorized user: %+v", sent)
+	}
+}
+
+// startChatAdmins starts a driver with a /warn command for the CHAT_ADMIN role,
+// where user 7 is an administrator of the chat -100
+func startChatAdmins(t *testing.T, ttl time.Duration) *parrbottest.Driver {
+	t.Helper()
+
+	config := robot.DefaultConfig()
+	config.ChatAdminsCacheTTL = ttl
+	d, err := parrbottest.Start(config, robot.Command{
+		Trigger:  "/warn",
+		ReplyAt:  message.MESSAGE,
+		Roles:    robot.CHAT_ADMIN,
+		CallFunc: answer("warned"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+
+	d.Handle("getChatMember", func(call parrbottest.Call) (interface{}, error) {
+		userID, _ := strconv.ParseInt(call.Params.Get("user_id"), 10, 64)
+		status := "member"
+		if call.ChatID() == -100 && userID == 7 {
+			status = "administrator"
+		}
+		return echotron.ChatMember{User: &echotron.User{ID: userID}, Status: status}, nil
+	})
+	return d
+}
+
+// warn sends /warn on the group -100 from the given user and returns the text of the reply, if any
+func warn(t *testing.T, d *parrbottest.Driver, userID int64) string {
+	t.Helper()
+
+	d.Reset()
+	err := d.Update(&echotron.Update{Message: &echotron.Message{
+		From: &echotron.User{ID: userID},
+		Chat: echotron.Chat{ID: -100, Type: "supergroup"},
+		Text: "/warn",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent := d.CallsTo("sendMessage"); len(sent) > 0 {
+		return sent[0].Text()
+	}
+	return ""
+}
+
+func TestChatAdminRole(t *testing.T) {
+	d := startChatAdmins(t, time.Hour)
+
+	if reply := warn(t, d, 7); reply != "warned" {
+		t.Errorf("reply to the administrator = %q, want \"warned\"", reply)
+	}
+	calls := d.CallsTo("getChatMember")
+	if len(calls) != 1 || calls[0].ChatID() != -100 || calls[0].Params.Get("user_id") != "7" {
+		t.Errorf("getChatMember calls = %+v, want one for user 7 on chat -100", calls)
+	}
+
+	if reply := warn(t, d, 42); reply != "" {
+		t.Errorf("reply to a member = %q, want none", reply)
+	}
+}
+
+func TestChatAdminCache(t *testing.T) {
+	cases := []struct {
+		name  string
+		ttl   time.Duration
+		calls int
+	}{
+		{"within TTL", time.Hour, 0},
+		{"expired", time.Nanosecond, 1},
+	}
+
+	for _, c := range cases {
+		d := startChatAdmins(t, c.ttl)
+		warn(t, d, 7)
+		if reply := warn(t, d, 7); reply != "warned" {
+			t.Errorf("%s: reply to the administrator = %q, want \"warned\"", c.name, reply)
+		}
+		if calls := d.CallsTo("getChatMember"); len(calls) != c.calls {
+			t.Errorf("%s: %d getChatMember calls for the second command, want %d", c.name, len(calls), c.calls)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package robot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DazFather/parrbot/message"
+)
+
+// schedulerChatID is the reserved chat ID under witch the jobs are saved on Config.SessionStore
+const schedulerChatID int64 = 0
+
+// TaskFunc is a function that can be scheduled (see RegisterTask). It runs on
+// the session of the chat of the job and the returned message will be sent on it
+type TaskFunc func(bot *Bot, job Job) message.Any
+
+// Job is a scheduled run (or series of runs) of a registered task on a chat
+type Job struct {
+	ID      string          `json:"id"`                // Identifier of the job, use it with CancelJob
+	ChatID  int64           `json:"chat_id"`           // Chat on which the task will run
+	Task    string          `json:"task"`              // Name of the task, see RegisterTask
+	Payload json.RawMessage `json:"payload,omitempty"` // Optional data passed to the task, see Decode
+	Next    time.Time       `json:"next"`              // Next time the task will run
+	Every   time.Duration   `json:"every,omitempty"`   // Interval between runs when created with ScheduleEvery
+	Cron    string          `json:"cron,omitempty"`    // Cron expression of the runs when created with ScheduleCron
+}
+
+// Decode unmarshals the payload of the job into dst
+func (j Job) Decode(dst any) error {
+	if len(j.Payload) == 0 {
+		return nil
+	}
+	return json.Unmarshal(j.Payload, dst)
+}
+
+// reschedule sets Next to the time of the following run after now and returns
+// false if there is none (one-shot jobs)
+func (j *Job) reschedule(now time.Time) bool {
+	switch {
+	case j.Every > 0:
+		if j.Next = j.Next.Add(j.Every); j.Next.Before(now) {
+			j.Next = now.Add(j.Every)
+		}
+	case j.Cron != "":
+		cron, err := ParseCron(j.Cron)
+		if err != nil {
+			return false
+		}
+		j.Next = cron.Next(now)
+	default:
+		return false
+	}
+	return !j.Next.IsZero()
+}
+
+// scheduled is a job with its timer
+type scheduled struct {
+	Job
+	timer *time.Timer
+}
+
+// scheduler keeps the jobs of a robot and runs them on the sessions of its dispatcher
+type scheduler struct {
+	robot *Robot
+	mu    sync.Mutex
+	tasks map[string]TaskFunc
+	jobs  map[string]*scheduled
+	d     *dispatcher // nil when the robot is not running
+}
+
+// newScheduler creates the scheduler of the given robot, without jobs
+func newScheduler(r *Robot) *scheduler {
+	return &scheduler{
+		robot: r,
+		tasks: make(map[string]TaskFunc),
+		jobs:  make(map[string]*scheduled),
+	}
+}
+
+// RegisterTask makes the function available to the jobs with the given name.
+// Tasks must be registered before robot.Start, so that the jobs saved on
+// Config.SessionStore can run again after a restart
+func RegisterTask(name string, fn TaskFunc) {
+	std.RegisterTask(name, fn)
+}
+
+// ScheduleIn schedules the task to run once on the given chat after the delay,
+// with the payload (that will be marshaled as JSON). It returns the job ID
+func ScheduleIn(delay time.Duration, chatID int64, task string, payload any) (string, error) {
+	return std.ScheduleIn(delay, chatID, task, payload)
+}
+
+// ScheduleEvery schedules the task to run on the given chat every interval,
+// starting after the first one, with the payload (that will be marshaled as JSON).
+// It returns the job ID
+func ScheduleEvery(interval time.Duration, chatID int64, task string, payload any) (string, error) {
+	return std.ScheduleEvery(interval, chatID, task, payload)
+}
+
+// ScheduleCron schedules the task to run on the given chat at the times matched
+// by the cron expression (see ParseCron), with the payload (that will be marshaled
+// as JSON). It returns the job ID
+func ScheduleCron(expr string, chatID int64, task string, payload any) (string, error) {
+	return std.ScheduleCron(expr, chatID, task, payload)
+}
+
+// CancelJob removes the job with the given ID, returns false if it doesn't exist
+func CancelJob(id string) bool {
+	return std.CancelJob(id)
+}
+
+// ScheduledJobs returns all the jobs that are still scheduled
+func ScheduledJobs() []Job {
+	return std.ScheduledJobs()
+}
+
+// RegisterTask works as the RegisterTask function, for the jobs of the robot
+func (r *Robot) RegisterTask(name string, fn TaskFunc) {
+	r.sched.mu.Lock()
+	r.sched.tasks[name] = fn
+	r.sched.mu.Unlock()
+}
+
+// ScheduleIn works as the ScheduleIn function, for the robot
+func (r *Robot) ScheduleIn(delay time.Duration, chatID int64, task string, payload any) (string, error) {
+	return r.sched.add(Job{ChatID: chatID, Task: task, Next: time.Now().Add(delay)}, payload)
+}
+
+// ScheduleEvery works as the ScheduleEvery function, for the robot
+func (r *Robot) ScheduleEvery(interval time.Duration, chatID int64, task string, payload any) (string, error) {
+	if interval <= 0 {
+		return "", errors.New("Interval must be positive")
+	}
+	return r.sched.add(Job{ChatID: chatID, Task: task, Next: time.Now().Add(interval), Every: interval}, payload)
+}
+
+// ScheduleCron works as the ScheduleCron function, for the robot
+func (r *Robot) ScheduleCron(expr string, chatID int64, task string, payload any) (string, error) {
+	cron, err := ParseCron(expr)
+	if err != nil {
+		return "", err
+	}
+	next := cron.Next(time.Now())
+	if next.IsZero() {
+		return "", errors.New("Cron expression never matches")
+	}
+	return r.sched.add(Job{ChatID: chatID, Task: task, Next: next, Cron: expr}, payload)
+}
+
+// CancelJob works as the CancelJob function, for the jobs of the robot
+func (r *Robot) CancelJob(id string) bool {
+	var s = r.sched
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.jobs[id]
+	if !ok {
+		return false
+	}
+	if entry.timer != nil {
+		entry.timer.Stop()
+	}
+	delete(s.jobs, id)
+	if err := s.save(); err != nil {
+		s.robot.Logger().Error("Unable to save the scheduled jobs", "error", err)
+	}
+	return true
+}
+
+// ScheduledJobs works as the ScheduledJobs function, for the jobs of the robot
+func (r *Robot) ScheduledJobs() (list []Job) {
+	var s = r.sched
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.jobs {
+		list = append(list, entry.Job)
+	}
+	return
+}
+
+// add schedules the job, after checking it and setting its ID and payload
+func (s *scheduler) add(job Job, payload any) (id string, err error) {
+	if job.ChatID == schedulerChatID {
+		return "", errors.New("Invalid chat ID")
+	}
+	if payload != nil {
+		if job.Payload, err = json.Marshal(payload); err != nil {
+			return "", err
+		}
+	}
+	if job.ID, err = newJobID(); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[job.Task]; !ok {
+		return "", fmt.Errorf("Task %q is not registered", job.Task)
+	}
+	entry := &scheduled{Job: job}
+	s.jobs[job.ID] = entry
+	s.arm(entry)
+	return job.ID, s.save()
+}
+
+// newJobID generates a random ID for a job
+func newJobID() (string, error) {
+	var id = make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// arm starts the timer of the job if the scheduler is running, mu must be locked
+func (s *scheduler) arm(entry *scheduled) {
+	if s.d == nil {
+		return
+	}
+	id := entry.ID
+	entry.timer = time.AfterFunc(time.Until(entry.Next), func() { s.fire(id) })
+}
+
+// fire runs the job with the given ID on a new goroutine and schedules the next run
+func (s *scheduler) fire(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the job could have been canceled, or rescheduled by a restart of the scheduler
+	entry, ok := s.jobs[id]
+	if !ok || s.d == nil {
+		return
+	}
+	// the timer can fire early when the wall clock is moved back (ex. by NTP),
+	// as the time of the jobs built with time.Date or loaded has no monotonic reading
+	if time.Now().Before(entry.Next) {
+		if entry.timer != nil {
+			entry.timer.Stop()
+		}
+		s.arm(entry)
+		return
+	}
+
+	var (
+		job = entry.Job
+		fn  = s.tasks[job.Task]
+		d   = s.d
+	)
+	if entry.reschedule(time.Now()) {
+		s.arm(entry)
+	} else {
+		delete(s.jobs, id)
+	}
+	if err := s.save(); err != nil {
+		s.robot.Logger().Error("Unable to save the scheduled jobs", "error", err)
+	}
+
+	if fn == nil {
+		s.robot.Logger().Error("Unable to run job: task not registered", "job_id", job.ID, "task", job.Task)
+		return
+	}
+	d.inflight.Add(1)
+	go func() {
+		defer d.inflight.Done()
+		d.instance(job.ChatID).run(fn, job)
+	}()
+}
+
+// run runs the task on the bot, sending the returned message and reporting
+// the errors and the panics as it happens for the updates
+func (b *Bot) run(fn TaskFunc, job Job) {
+	defer func() {
+		if value := recover(); value != nil {
+			b.reportPanic(newPanicError(value), nil, nil, "job_id", job.ID, "task", job.Task)
+		}
+	}()
+
+	b.send(nil, fn(b, job), "job_id", job.ID, "task", job.Task)
+}
+
+// save writes all the jobs on Config.SessionStore, mu must be locked
+func (s *scheduler) save() error {
+	var store = s.robot.config.SessionStore
+	if store == nil {
+		return nil
+	}
+	if len(s.jobs) == 0 {
+		return store.Delete(schedulerChatID)
+	}
+
+	var list = make([]Job, 0, len(s.jobs))
+	for _, entry := range s.jobs {
+		list = append(list, entry.Job)
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	return store.Save(schedulerChatID, data)
+}
+
+// start loads the jobs saved on Config.SessionStore and starts the timers of
+// all the jobs, running them on the sessions of the given dispatcher
+func (s *scheduler) start(d *dispatcher) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var data []byte
+	if store := s.robot.config.SessionStore; store != nil {
+		if data, err = store.Load(schedulerChatID); err != nil {
+			return
+		}
+	}
+	if data != nil {
+		var list []Job
+		if err = json.Unmarshal(data, &list); err != nil {
+			return
+		}
+		for _, job := range list {
+			if _, ok := s.jobs[job.ID]; !ok {
+				s.jobs[job.ID] = &scheduled{Job: job}
+			}
+		}
+	}
+
+	s.d = d
+	for _, entry := range s.jobs {
+		s.arm(entry)
+	}
+	return s.save()
+}
+
+// stop stops the timers of all the jobs, they will start again on the next start
+func (s *scheduler) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.jobs {
+		if entry.timer != nil {
+			entry.timer.Stop()
+			entry.timer = nil
+		}
+	}
+	s.d = nil
+}
@@ -0,0 +1,166 @@
+package robot_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+)
+
+// say is a task that sends the text in the payload of the job
+func say(bot *robot.Bot, job robot.Job) message.Any {
+	var text string
+	if err := job.Decode(&text); err != nil {
+		return nil
+	}
+	return message.Text{Text: text}
+}
+
+// runs returns a task that notifies its runs on the returned channel
+func runs() (robot.TaskFunc, <-chan robot.Job) {
+	var ch = make(chan robot.Job, 16)
+	return func(bot *robot.Bot, job robot.Job) message.Any {
+		ch <- job
+		return nil
+	}, ch
+}
+
+func TestScheduleIn(t *testing.T) {
+	d := startDriver(t)
+	d.Robot.RegisterTask("say", say)
+
+	id, err := d.Robot.ScheduleIn(20*time.Millisecond, 42, "say", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jobs := d.Robot.ScheduledJobs(); len(jobs) != 1 || jobs[0].ID != id {
+		t.Errorf("scheduled jobs = %+v, want %s", jobs, id)
+	}
+
+	call, ok := d.WaitFor("sendMessage", 2*time.Second)
+	if !ok {
+		t.Fatal("job did not run")
+	}
+	if call.ChatID() != 42 || call.Text() != "hello" {
+		t.Errorf("job sent %q on chat %d, want \"hello\" on 42", call.Text(), call.ChatID())
+	}
+	if jobs := d.Robot.ScheduledJobs(); len(jobs) != 0 {
+		t.Errorf("one-shot job still scheduled: %+v", jobs)
+	}
+	if d.Robot.CancelJob(id) {
+		t.Error("CancelJob of a job already run returned true")
+	}
+}
+
+func TestScheduleErrors(t *testing.T) {
+	r := robot.New(robot.DefaultConfig())
+	r.RegisterTask("say", say)
+
+	if _, err := r.ScheduleIn(time.Minute, 42, "missing", nil); err == nil {
+		t.Error("scheduled a task not registered")
+	}
+	if _, err := r.ScheduleIn(time.Minute, 0, "say", nil); err == nil {
+		t.Error("scheduled a task on chat 0")
+	}
+	if _, err := r.ScheduleEvery(0, 42, "say", nil); err == nil {
+		t.Error("scheduled a task every 0")
+	}
+	if _, err := r.ScheduleCron("0 0 30 2 *", 42, "say", nil); err == nil {
+		t.Error("scheduled a cron that never matches")
+	}
+}
+
+func TestScheduleEvery(t *testing.T) {
+	d := startDriver(t)
+	task, ran := runs()
+	d.Robot.RegisterTask("tick", task)
+
+	id, err := d.Robot.ScheduleEvery(20*time.Millisecond, 42, "tick", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case job := <-ran:
+			if job.ID != id || job.ChatID != 42 {
+				t.Errorf("run %d of job %s on chat %d, want %s on 42", i, job.ID, job.ChatID, id)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("run %d did not happen", i)
+		}
+	}
+
+	if !d.Robot.CancelJob(id) {
+		t.Fatal("CancelJob returned false")
+	}
+	if jobs := d.Robot.ScheduledJobs(); len(jobs) != 0 {
+		t.Errorf("canceled job still scheduled: %+v", jobs)
+	}
+
+	// a run could already be started while canceling
+	time.Sleep(50 * time.Millisecond)
+	for len(ran) > 0 {
+		<-ran
+	}
+	select {
+	case <-ran:
+		t.Error("canceled job ran again")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	d := startDriver(t)
+	task, ran := runs()
+	d.Robot.RegisterTask("tick", task)
+
+	id, err := d.Robot.ScheduleIn(50*time.Millisecond, 42, "tick", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Robot.CancelJob(id) {
+		t.Fatal("CancelJob returned false")
+	}
+	if d.Robot.CancelJob(id) {
+		t.Error("CancelJob of a job already canceled returned true")
+	}
+
+	select {
+	case <-ran:
+		t.Error("canceled job ran")
+	case <-time.After(150 * time.Millisecond):
+	}
+}
+
+func TestScheduledJobsReload(t *testing.T) {
+	var config = robot.DefaultConfig()
+	config.SessionStore = robot.NewMemoryStore()
+
+	// Jobs scheduled by a previous run are saved on the store
+	previous := robot.New(config)
+	previous.RegisterTask("say", say)
+	id, err := previous.ScheduleIn(300*time.Millisecond, 42, "say", "still here")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := parrbottest.Start(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	d.Robot.RegisterTask("say", say)
+
+	if jobs := d.Robot.ScheduledJobs(); len(jobs) != 1 || jobs[0].ID != id {
+		t.Fatalf("reloaded jobs = %+v, want %s", jobs, id)
+	}
+	call, ok := d.WaitFor("sendMessage", 2*time.Second)
+	if !ok {
+		t.Fatal("reloaded job did not run")
+	}
+	if call.ChatID() != 42 || call.Text() != "still here" {
+		t.Errorf("reloaded job sent %q on chat %d, want \"still here\" on 42", call.Text(), call.ChatID())
+	}
+}
@@ -0,0 +1,220 @@
+package robot
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// SessionStore is where the data of the sessions (see Bot.SetData) is saved, so
+// that it can survive the session expiry or, depending on the implementation,
+// the restart of the program. Use Config.SessionStore to change it
+type SessionStore interface {
+	// Load returns the data saved for the given chat, or nil if there is none
+	Load(chatID int64) ([]byte, error)
+
+	// Save saves the data of the given chat, replacing the previous one
+	Save(chatID int64, data []byte) error
+
+	// Delete removes the data of the given chat
+	Delete(chatID int64) error
+}
+
+// memoryStore is a SessionStore that keeps the data in memory
+type memoryStore struct {
+	mu   sync.RWMutex
+	data map[int64][]byte
+}
+
+// NewMemoryStore creates a SessionStore that keeps the data in memory. Data will
+// survive the session expiry but not the restart of the program
+func NewMemoryStore() SessionStore {
+	return &memoryStore{data: make(map[int64][]byte)}
+}
+
+// Load returns the data saved for the given chat, or nil if there is none
+func (s *memoryStore) Load(chatID int64) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.data[chatID], nil
+}
+
+// Save saves the data of the given chat, replacing the previous one
+func (s *memoryStore) Save(chatID int64, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[chatID] = append([]byte(nil), data...)
+	return nil
+}
+
+// Delete removes the data of the given chat
+func (s *memoryStore) Delete(chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data, chatID)
+	return nil
+}
+
+// fileStore is a SessionStore that keeps the data of each chat in a JSON file
+type fileStore struct {
+	mu        sync.Mutex
+	directory string
+}
+
+// NewFileStore creates a SessionStore that keeps the data of each chat in a
+// different JSON file ("<chatID>.json") inside the given directory, that will
+// be created if missing. Data will survive both session expiry and restarts
+func NewFileStore(directory string) (SessionStore, error) {
+	if err := os.MkdirAll(directory, 0o700); err != nil {
+		return nil, err
+	}
+	return &fileStore{directory: directory}, nil
+}
+
+// path returns the path of the file of the given chat
+func (s *fileStore) path(chatID int64) string {
+	return filepath.Join(s.directory, strconv.FormatInt(chatID, 10)+".json")
+}
+
+// Load returns the data saved for the given chat, or nil if there is none
+func (s *fileStore) Load(chatID int64) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path(chatID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Save saves the data of the given chat, replacing the previous one. To avoid
+// corrupted files, data is first written on a temporary file that is then renamed
+func (s *fileStore) Save(chatID int64, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(s.directory, "session-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path(chatID))
+}
+
+// Delete removes the data of the given chat
+func (s *fileStore) Delete(chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.path(chatID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+/* --- Session data --- */
+
+// loadData loads the session data of the bot from the store
+func (b *Bot) loadData() error {
+	var store = b.config().SessionStore
+	if store == nil {
+		return nil
+	}
+
+	raw, err := store.Load(b.ChatID)
+	if err != nil || raw == nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return json.Unmarshal(raw, &b.data)
+}
+
+// saveData saves the session data of the bot on the store, b.mu must be locked
+func (b *Bot) saveData() error {
+	var store = b.config().SessionStore
+	if store == nil {
+		return nil
+	}
+
+	if len(b.data) == 0 {
+		return store.Delete(b.ChatID)
+	}
+
+	raw, err := json.Marshal(b.data)
+	if err != nil {
+		return err
+	}
+	return store.Save(b.ChatID, raw)
+}
+
+// SetData saves the given value with the given key on the session data, that
+// will be kept on Config.SessionStore. Value needs to be encodable in JSON
+func (b *Bot) SetData(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.data == nil {
+		b.data = make(map[string]json.RawMessage)
+	}
+	b.data[key] = raw
+	return b.saveData()
+}
+
+// GetData decodes the value saved with the given key on the session data into
+// the value pointed by dst. It returns false if there is no value for that key
+func (b *Bot) GetData(key string, dst any) (bool, error) {
+	b.mu.Lock()
+	raw, ok := b.data[key]
+	b.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, dst)
+}
+
+// DeleteData removes the value saved with the given key from the session data
+func (b *Bot) DeleteData(key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.data[key]; !ok {
+		return nil
+	}
+	delete(b.data, key)
+	return b.saveData()
+}
+
+// ClearData removes all the session data, also from the store
+func (b *Bot) ClearData() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data = nil
+	return b.saveData()
+}
@@ -0,0 +1,46 @@
+package robot_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/DazFather/parrbot/robot"
+)
+
+func ExampleBot_SetData() {
+	var bot = &robot.Bot{ChatID: 42}
+
+	bot.SetData("name", "Polly")
+
+	var name string
+	found, err := bot.GetData("name", &name)
+	fmt.Println(name, found, err)
+
+	found, err = bot.GetData("age", new(int))
+	fmt.Println(found, err)
+	// Output:
+	// Polly true <nil>
+	// false <nil>
+}
+
+func ExampleNewFileStore() {
+	directory, _ := os.MkdirTemp("", "parrbot-sessions")
+	defer os.RemoveAll(directory)
+
+	store, err := robot.NewFileStore(directory)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	store.Save(42, []byte(`{"name":"Polly"}`))
+	data, _ := store.Load(42)
+	fmt.Println(string(data))
+
+	store.Delete(42)
+	data, _ = store.Load(42)
+	fmt.Println(data == nil)
+	// Output:
+	// {"name":"Polly"}
+	// true
+}
@@ -0,0 +1,259 @@
+package robot
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// settings are the configurations that can be loaded from a config file, the
+// environment variables or the command line flags. Empty values are ignored
+type settings struct {
+	Token              string  `json:"token"`
+	TokenFile          string  `json:"token_file"`           // file containing only the token
+	OwnerID            int64   `json:"owner_id"`             // see ParrbotConfig.OwnerID
+	AdminIDs           []int64 `json:"admin_ids"`            // see ParrbotConfig.AdminIDs
+	DeveloperChatID    int64   `json:"developer_chat_id"`    // see ParrbotConfig.DeveloperChatID
+	DeleteSessionTimer string  `json:"delete_session_timer"` // duration, ex. "2h" or "0" to keep the sessions active
+	ShutdownTimeout    string  `json:"shutdown_timeout"`     // duration, ex. "10s"
+	SessionDir         string  `json:"session_dir"`          // directory of the NewFileStore used as SessionStore
+	WebhookURL         string  `json:"webhook_url"`          // see WebhookConfig.URL
+	WebhookSecret      string  `json:"webhook_secret"`       // see WebhookConfig.SecretToken
+	WebhookListen      string  `json:"webhook_listen"`       // see WebhookConfig.ListenAddr
+	WebhookCertFile    string  `json:"webhook_cert_file"`    // see WebhookConfig.CertFile
+	WebhookKeyFile     string  `json:"webhook_key_file"`     // see WebhookConfig.KeyFile
+}
+
+// apply sets the non-empty values on the configuration
+func (s settings) apply(c *ParrbotConfig) (err error) {
+	if s.TokenFile != "" {
+		if s.Token, err = readToken(s.TokenFile); err != nil {
+			return
+		}
+	}
+	if s.Token != "" {
+		if err = c.SetAPIToken(s.Token); err != nil {
+			return
+		}
+	}
+
+	if s.OwnerID != 0 {
+		c.OwnerID = s.OwnerID
+	}
+	if s.AdminIDs != nil {
+		c.AdminIDs = s.AdminIDs
+	}
+	if s.DeveloperChatID != 0 {
+		c.DeveloperChatID = s.DeveloperChatID
+	}
+
+	if s.DeleteSessionTimer != "" {
+		if c.DeleteSessionTimer, err = time.ParseDuration(s.DeleteSessionTimer); err != nil {
+			return
+		}
+	}
+	if s.ShutdownTimeout != "" {
+		if c.ShutdownTimeout, err = time.ParseDuration(s.ShutdownTimeout); err != nil {
+			return
+		}
+	}
+
+	if s.SessionDir != "" {
+		if c.SessionStore, err = NewFileStore(s.SessionDir); err != nil {
+			return
+		}
+	}
+
+	if s.WebhookURL != "" {
+		c.Webhook = &WebhookConfig{
+			URL:         s.WebhookURL,
+			SecretToken: s.WebhookSecret,
+			ListenAddr:  s.WebhookListen,
+			CertFile:    s.WebhookCertFile,
+			KeyFile:     s.WebhookKeyFile,
+		}
+	}
+	return
+}
+
+// readToken reads the token from the given file
+func readToken(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// parseIDs parses a list of IDs separated by commas, ex. "1,2,3"
+func parseIDs(list string) (ids []int64, err error) {
+	for _, field := range strings.Split(list, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return
+}
+
+// envPrefix is the prefix of all the environment variables read by the bot
+const envPrefix = "PARRBOT_"
+
+// envSettings reads the settings from the environment variables: PARRBOT_TOKEN,
+// PARRBOT_TOKEN_FILE, PARRBOT_OWNER_ID, PARRBOT_ADMIN_IDS, PARRBOT_DEVELOPER_CHAT_ID,
+// PARRBOT_DELETE_SESSION_TIMER, PARRBOT_SHUTDOWN_TIMEOUT, PARRBOT_SESSION_DIR,
+// PARRBOT_WEBHOOK_URL, PARRBOT_WEBHOOK_SECRET, PARRBOT_WEBHOOK_LISTEN,
+// PARRBOT_WEBHOOK_CERT_FILE and PARRBOT_WEBHOOK_KEY_FILE
+func envSettings() (s settings, err error) {
+	var env = func(name string) string {
+		return os.Getenv(envPrefix + name)
+	}
+	var id = func(name string) (int64, error) {
+		if value := env(name); value != "" {
+			return strconv.ParseInt(value, 10, 64)
+		}
+		return 0, nil
+	}
+
+	s.Token = env("TOKEN")
+	s.TokenFile = env("TOKEN_FILE")
+	if s.OwnerID, err = id("OWNER_ID"); err != nil {
+		return s, fmt.Errorf("%sOWNER_ID: %w", envPrefix, err)
+	}
+	if list := env("ADMIN_IDS"); list != "" {
+		if s.AdminIDs, err = parseIDs(list); err != nil {
+			return s, fmt.Errorf("%sADMIN_IDS: %w", envPrefix, err)
+		}
+	}
+	if s.DeveloperChatID, err = id("DEVELOPER_CHAT_ID"); err != nil {
+		return s, fmt.Errorf("%sDEVELOPER_CHAT_ID: %w", envPrefix, err)
+	}
+	s.DeleteSessionTimer = env("DELETE_SESSION_TIMER")
+	s.ShutdownTimeout = env("SHUTDOWN_TIMEOUT")
+	s.SessionDir = env("SESSION_DIR")
+	s.WebhookURL = env("WEBHOOK_URL")
+	s.WebhookSecret = env("WEBHOOK_SECRET")
+	s.WebhookListen = env("WEBHOOK_LISTEN")
+	s.WebhookCertFile = env("WEBHOOK_CERT_FILE")
+	s.WebhookKeyFile = env("WEBHOOK_KEY_FILE")
+	return
+}
+
+// fileSettings reads the settings from the given JSON file
+func fileSettings(path string) (s settings, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(content, &s)
+	return
+}
+
+// flagSettings are the settings given with the command line flags, see RegisterFlags
+type flagSettings struct {
+	settings
+	set        *flag.FlagSet
+	configFile string
+}
+
+// LoadFile loads the configurations from the given JSON file, ex:
+//
+//	{"token": "123:ABC", "owner_id": 42, "delete_session_timer": "1h"}
+//
+// See the README for all the keys. When Start runs, environment variables and
+// flags (see RegisterFlags) will still override them
+func (c *ParrbotConfig) LoadFile(path string) error {
+	s, err := fileSettings(path)
+	if err != nil {
+		return err
+	}
+	return s.apply(c)
+}
+
+// RegisterFlags defines the configuration flags on the given set (ex. flag.CommandLine)
+// with the names prefixed by prefix, so that they can coexist with the ones of
+// your application: -token, -token-file (or -readfrom), -config, -owner-id,
+// -admin-ids, -developer-chat-id, -delete-session-timer, -shutdown-timeout,
+// -session-dir, -webhook-url, -webhook-secret, -webhook-listen, -webhook-cert-file
+// and -webhook-key-file.
+// Parse the set before Start, if it is flag.CommandLine and it has not been
+// parsed yet, Start will do it
+func (c *ParrbotConfig) RegisterFlags(set *flag.FlagSet, prefix string) {
+	var (
+		f   = &flagSettings{set: set}
+		str = func(name, usage string, dst *string) {
+			set.Func(prefix+name, usage, func(value string) error {
+				*dst = value
+				return nil
+			})
+		}
+		id = func(name, usage string, dst *int64) {
+			set.Func(prefix+name, usage, func(value string) (err error) {
+				*dst, err = strconv.ParseInt(value, 10, 64)
+				return
+			})
+		}
+	)
+
+	str("token", "Telegram Bot API token", &f.Token)
+	str("token-file", "file containing the Telegram Bot API token", &f.TokenFile)
+	str("readfrom", "same as -"+prefix+"token-file", &f.TokenFile)
+	str("config", "JSON configuration file", &f.configFile)
+	id("owner-id", "ID of the owner of the bot", &f.OwnerID)
+	set.Func(prefix+"admin-ids", "IDs of the admins of the bot, separated by commas", func(value string) (err error) {
+		f.AdminIDs, err = parseIDs(value)
+		return
+	})
+	id("developer-chat-id", "ID of the chat where panics will be reported", &f.DeveloperChatID)
+	str("delete-session-timer", "time after witch an inactive session is deleted, 0 to keep them active", &f.DeleteSessionTimer)
+	str("shutdown-timeout", "max time to wait for the running handlers when the bot stops", &f.ShutdownTimeout)
+	str("session-dir", "directory where the session data is saved", &f.SessionDir)
+	str("webhook-url", "public URL where Telegram will send the updates", &f.WebhookURL)
+	str("webhook-secret", "secret token of the webhook", &f.WebhookSecret)
+	str("webhook-listen", "local address of the webhook server", &f.WebhookListen)
+	str("webhook-cert-file", "certificate file of the HTTPS webhook server", &f.WebhookCertFile)
+	str("webhook-key-file", "private key file of the HTTPS webhook server", &f.WebhookKeyFile)
+
+	c.flags = f
+}
+
+// load applies, in order of precedence (lowest first): the config file (given
+// by the -config flag or PARRBOT_CONFIG), the environment variables and the flags
+func (c *ParrbotConfig) load() error {
+	var f = c.flags
+	if f != nil && f.set == flag.CommandLine && !flag.Parsed() {
+		flag.Parse()
+	}
+
+	env, err := envSettings()
+	if err != nil {
+		return err
+	}
+
+	var path = os.Getenv(envPrefix + "CONFIG")
+	if f != nil && f.configFile != "" {
+		path = f.configFile
+	}
+	if path != "" {
+		file, err := fileSettings(path)
+		if err != nil {
+			return fmt.Errorf("Config file error: %w", err)
+		}
+		if err = file.apply(c); err != nil {
+			return err
+		}
+	}
+
+	if err = env.apply(c); err != nil {
+		return err
+	}
+	if f != nil {
+		return f.apply(c)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package robot
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets the environment variables read by the bot for the duration of the test
+func clearEnv(t *testing.T) {
+	for _, name := range []string{"CONFIG", "TOKEN", "TOKEN_FILE", "OWNER_ID", "ADMIN_IDS", "DEVELOPER_CHAT_ID", "DELETE_SESSION_TIMER", "SHUTDOWN_TIMEOUT", "SESSION_DIR", "WEBHOOK_URL", "WEBHOOK_SECRET", "WEBHOOK_LISTEN", "WEBHOOK_CERT_FILE", "WEBHOOK_KEY_FILE"} {
+		t.Setenv(envPrefix+name, "")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{"token": "1:FILE", "owner_id": 1, "developer_chat_id": 1, "shutdown_timeout": "1s"}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PARRBOT_CONFIG", path)
+	t.Setenv("PARRBOT_TOKEN", "2:ENV")
+	t.Setenv("PARRBOT_OWNER_ID", "2")
+
+	cases := []struct {
+		name      string
+		flags     []string
+		token     string
+		ownerID   int64
+		developer int64
+	}{
+		{"file and env", nil, "2:ENV", 2, 1},
+		{"flags", []string{"-token", "3:FLAG", "-developer-chat-id", "3"}, "3:FLAG", 2, 3},
+	}
+
+	for _, c := range cases {
+		config := DefaultConfig()
+		config.OwnerID, config.DeveloperChatID, config.ShutdownTimeout = 9, 9, time.Hour
+		if c.flags != nil {
+			set := flag.NewFlagSet("bot", flag.ContinueOnError)
+			config.RegisterFlags(set, "")
+			if err := set.Parse(c.flags); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := config.load(); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if config.token != c.token || config.OwnerID != c.ownerID || config.DeveloperChatID != c.developer {
+			t.Errorf("%s: token %q, owner %d, developer chat %d, want %q, %d, %d",
+				c.name, config.token, config.OwnerID, config.DeveloperChatID, c.token, c.ownerID, c.developer)
+		}
+		// set only by the file, so it overrides the code
+		if config.ShutdownTimeout != time.Second {
+			t.Errorf("%s: shutdown timeout %v, want the one of the file", c.name, config.ShutdownTimeout)
+		}
+	}
+}
+
+func TestFlagsDisableArgsToken(t *testing.T) {
+	clearEnv(t)
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"bot", "123:ARGS"}
+
+	config := DefaultConfig()
+	if err := config.init(true); err != nil || config.token != "123:ARGS" {
+		t.Fatalf("token without flags = %q, %v, want the one of os.Args", config.token, err)
+	}
+
+	config = DefaultConfig()
+	config.RegisterFlags(flag.NewFlagSet("bot", flag.ContinueOnError), "")
+	if err := config.init(true); err == nil {
+		t.Errorf("token with flags = %q, want an error as os.Args is not used", config.token)
+	}
+}
@@ -0,0 +1,68 @@
+package robot
+
+import "encoding/json"
+
+// Key is a typed key of the session data that allows handlers to read and write
+// values of a specific type without dealing with JSON, ex:
+//
+//	var counter = robot.Key[int]("counter")
+//	counter.Update(bot, func(n int) int { return n + 1 })
+//
+// Values are kept on Config.SessionStore as for Bot.SetData
+type Key[T any] string
+
+// Get returns the value saved with the key on the session of the given bot.
+// If missing, or if the saved value is not of type T, found will be false
+func (k Key[T]) Get(b *Bot) (value T, found bool) {
+	found, err := b.GetData(string(k), &value)
+	if err != nil {
+		var zero T
+		return zero, false
+	}
+	return value, found
+}
+
+// GetOr returns the value saved with the key on the session of the given bot
+// or the given fallback if missing
+func (k Key[T]) GetOr(b *Bot, fallback T) T {
+	if value, found := k.Get(b); found {
+		return value
+	}
+	return fallback
+}
+
+// Set saves the given value with the key on the session of the given bot
+func (k Key[T]) Set(b *Bot, value T) error {
+	return b.SetData(string(k), value)
+}
+
+// Delete removes the value saved with the key from the session of the given bot
+func (k Key[T]) Delete(b *Bot) error {
+	return b.DeleteData(string(k))
+}
+
+// Update replaces the value saved with the key with the one returned by fn,
+// that receives the current value (or the zero value if missing). The whole
+// operation is atomic, so it's safe to use with concurrent updates of the same chat
+func (k Key[T]) Update(b *Bot, fn func(value T) T) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var value T
+	if raw, ok := b.data[string(k)]; ok {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+	}
+
+	raw, err := json.Marshal(fn(value))
+	if err != nil {
+		return err
+	}
+
+	if b.data == nil {
+		b.data = make(map[string]json.RawMessage)
+	}
+	b.data[string(k)] = raw
+	return b.saveData()
+}
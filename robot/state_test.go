@@ -0,0 +1,35 @@
+package robot_test
+
+import (
+	"fmt"
+
+	"github.com/DazFather/parrbot/robot"
+)
+
+type profile struct {
+	Name string
+	Age  int
+}
+
+func ExampleKey() {
+	var (
+		bot      = &robot.Bot{ChatID: 7}
+		user     = robot.Key[profile]("profile")
+		messages = robot.Key[int]("messages")
+	)
+
+	user.Set(bot, profile{Name: "Polly", Age: 3})
+	messages.Update(bot, func(n int) int { return n + 1 })
+	messages.Update(bot, func(n int) int { return n + 1 })
+
+	p, found := user.Get(bot)
+	fmt.Println(p.Name, p.Age, found)
+	fmt.Println(messages.GetOr(bot, 0))
+
+	user.Delete(bot)
+	fmt.Println(user.GetOr(bot, profile{Name: "nobody"}).Name)
+	// Output:
+	// Polly 3 true
+	// 2
+	// nobody
+}
@@ -0,0 +1,89 @@
+package robot
+
+import (
+	"time"
+
+	"github.com/DazFather/parrbot/message"
+)
+
+// step is the handler waiting for the next update of a session
+type step struct {
+	handler CommandFunc
+	filter  message.UpdateType
+	timer   *time.Timer
+}
+
+// Await makes the next incoming message (message.MESSAGE) of the chat that
+// doesn't carry any command be handled by the given handler instead of the
+// command list. Useful for multi-step conversations
+func (b *Bot) Await(handler CommandFunc) {
+	b.WaitFor(message.MESSAGE, 0, handler)
+}
+
+// WaitFor makes the next update of the chat, of one of the types included in
+// filter, that doesn't carry any command be handled by the given handler
+// instead of the command list. Unknown commands still go to the UnknownTrigger
+// one. When timeout is not 0 the handler will be discarded after it expires.
+// Calling it again will replace the previous one
+func (b *Bot) WaitFor(filter message.UpdateType, timeout time.Duration, handler CommandFunc) {
+	var s = &step{handler: handler, filter: filter}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stopWaiting()
+	if timeout > 0 {
+		s.timer = time.AfterFunc(timeout, func() {
+			b.mu.Lock()
+			if b.next == s {
+				b.next = nil
+			}
+			b.mu.Unlock()
+		})
+	}
+	b.next = s
+}
+
+// CancelWait discards the handler set using Await or WaitFor, if any.
+// Returns true if there was a handler waiting
+func (b *Bot) CancelWait() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.stopWaiting()
+}
+
+// IsWaiting returns true if there is a handler waiting for the next update
+func (b *Bot) IsWaiting() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.next != nil
+}
+
+// stopWaiting removes the waiting step stopping its timer, b.mu must be locked
+func (b *Bot) stopWaiting() bool {
+	if b.next == nil {
+		return false
+	}
+	if b.next.timer != nil {
+		b.next.timer.Stop()
+	}
+	b.next = nil
+	return true
+}
+
+// popStep removes and returns the handler of the waiting step if it accepts
+// the given type of update, otherwise returns nil
+func (b *Bot) popStep(filter message.UpdateType) CommandFunc {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.next == nil || b.next.filter&filter == 0 {
+		return nil
+	}
+
+	handler := b.next.handler
+	b.stopWaiting()
+	return Chain(handler, b.config().Middlewares...)
+}
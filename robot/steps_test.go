@@ -0,0 +1,185 @@
+package robot_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+// startDriver starts a parrbottest.Driver with the given commands, closing it at the end of the test
+func startDriver(t *testing.T, commandList ...robot.Command) *parrbottest.Driver {
+	t.Helper()
+
+	d, err := parrbottest.Start(robot.DefaultConfig(), commandList...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+// expectReply sends the text on the chat and checks the text of the last message of the chat
+func expectReply(t *testing.T, d *parrbottest.Driver, chatID int64, text, want string) {
+	t.Helper()
+
+	if _, err := d.SendMessage(chatID, text); err != nil {
+		t.Fatal(err)
+	}
+	if last, _ := d.LastMessage(chatID); last.Text != want {
+		t.Errorf("reply to %q = %q, want %q", text, last.Text, want)
+	}
+}
+
+// answer returns a CommandFunc that replies with the given text
+func answer(text string) robot.CommandFunc {
+	return func(*robot.Bot, *message.Update) message.Any {
+		return message.Text{Text: text}
+	}
+}
+
+// callback returns the update of a callback query with the given data on msg
+func callback(msg echotron.Message, data string) *echotron.Update {
+	return &echotron.Update{CallbackQuery: &echotron.CallbackQuery{
+		ID:      "1",
+		From:    &echotron.User{ID: msg.Chat.ID},
+		Message: &msg,
+		Data:    data,
+	}}
+}
+
+func TestAwait(t *testing.T) {
+	d := startDriver(t,
+		robot.Command{
+			Trigger: "/name",
+			ReplyAt: message.MESSAGE,
+			CallFunc: func(bot *robot.Bot, update *message.Update) message.Any {
+				bot.Await(func(bot *robot.Bot, update *message.Update) message.Any {
+					return message.Text{Text: "Hello " + update.Message.Text}
+				})
+				return message.Text{Text: "What's your name?"}
+			},
+		},
+		robot.Command{Trigger: "/ping", ReplyAt: message.MESSAGE, CallFunc: answer("pong")},
+		robot.Command{Trigger: robot.UnknownTrigger, ReplyAt: message.MESSAGE, CallFunc: answer("unknown")},
+		robot.Command{Trigger: robot.DefaultTrigger, ReplyAt: message.MESSAGE, CallFunc: answer("default")},
+	)
+
+	expectReply(t, d, 42, "/name", "What's your name?")
+	expectReply(t, d, 42, "/ping", "pong")    // commands keep working
+	expectReply(t, d, 42, "/foo", "unknown")  // unknown commands are not answers
+	expectReply(t, d, 7, "Alice", "default")  // other chats are not waited
+	expectReply(t, d, 42, "Bob", "Hello Bob") // the answer
+	expectReply(t, d, 42, "Carl", "default")  // the step is used once
+}
+
+func TestAwaitBeforePattern(t *testing.T) {
+	d := startDriver(t,
+		robot.Command{
+			Trigger: "/name",
+			ReplyAt: message.MESSAGE,
+			CallFunc: func(bot *robot.Bot, update *message.Update) message.Any {
+				bot.Await(func(bot *robot.Bot, update *message.Update) message.Any {
+					return message.Text{Text: "Hello " + update.Message.Text}
+				})
+				return message.Text{Text: "What's your name?"}
+			},
+		},
+		robot.Command{Pattern: regexp.MustCompile(`(?i)^hello`), ReplyAt: message.MESSAGE, CallFunc: answer("keyword hi")},
+	)
+
+	expectReply(t, d, 42, "/name", "What's your name?")
+	expectReply(t, d, 42, "Hello Kitty", "Hello Hello Kitty") // the answer, even if it matches the pattern
+	expectReply(t, d, 42, "Hello Kitty", "keyword hi")        // then the pattern works again
+}
+
+func TestWaitFor(t *testing.T) {
+	var session *robot.Bot
+	d := startDriver(t,
+		robot.Command{
+			Trigger: "/vote",
+			ReplyAt: message.MESSAGE,
+			CallFunc: func(bot *robot.Bot, update *message.Update) message.Any {
+				session = bot
+				bot.WaitFor(message.CALLBACK_QUERY, 0, answer("voted"))
+				return message.Text{Text: "Choose"}
+			},
+		},
+		robot.Command{Trigger: robot.DefaultTrigger, ReplyAt: message.MESSAGE, CallFunc: answer("default")},
+	)
+
+	expectReply(t, d, 42, "/vote", "Choose")
+	expectReply(t, d, 42, "yes", "default") // messages are filtered out
+	if !session.IsWaiting() {
+		t.Fatal("step discarded by an update of another type")
+	}
+
+	sent, _ := d.LastMessage(42)
+	d.Update(callback(sent, "yes"))
+	if last, _ := d.LastMessage(42); last.Text != "voted" {
+		t.Errorf("reply to the callback = %q, want voted", last.Text)
+	}
+	if session.IsWaiting() {
+		t.Error("step still waiting after being used")
+	}
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	var session *robot.Bot
+	d := startDriver(t,
+		robot.Command{
+			Trigger: "/quiz",
+			ReplyAt: message.MESSAGE,
+			CallFunc: func(bot *robot.Bot, update *message.Update) message.Any {
+				session = bot
+				bot.WaitFor(message.MESSAGE, 20*time.Millisecond, answer("right"))
+				return message.Text{Text: "Quick!"}
+			},
+		},
+		robot.Command{Trigger: robot.DefaultTrigger, ReplyAt: message.MESSAGE, CallFunc: answer("too late")},
+	)
+
+	expectReply(t, d, 42, "/quiz", "Quick!")
+	time.Sleep(100 * time.Millisecond)
+	if session.IsWaiting() {
+		t.Error("step still waiting after the timeout")
+	}
+	expectReply(t, d, 42, "42", "too late")
+}
+
+func TestCancelWait(t *testing.T) {
+	var cancelled []bool
+	d := startDriver(t,
+		robot.Command{
+			Trigger: "/ask",
+			ReplyAt: message.MESSAGE,
+			CallFunc: func(bot *robot.Bot, update *message.Update) message.Any {
+				bot.WaitFor(message.MESSAGE, time.Hour, answer("answered"))
+				return message.Text{Text: "Ask me"}
+			},
+		},
+		robot.Command{
+			Trigger: "/cancel",
+			ReplyAt: message.MESSAGE,
+			CallFunc: func(bot *robot.Bot, update *message.Update) message.Any {
+				cancelled = append(cancelled, bot.CancelWait())
+				return message.Text{Text: "Cancelled"}
+			},
+		},
+		robot.Command{Trigger: robot.DefaultTrigger, ReplyAt: message.MESSAGE, CallFunc: answer("default")},
+	)
+
+	expectReply(t, d, 42, "/ask", "Ask me")
+	expectReply(t, d, 42, "/cancel", "Cancelled")
+	expectReply(t, d, 42, "/cancel", "Cancelled")
+	expectReply(t, d, 42, "hi", "default")
+
+	if len(cancelled) != 2 || !cancelled[0] || cancelled[1] {
+		t.Errorf("CancelWait returned %v, want [true false]", cancelled)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"os"
 	"regexp"
-	"strings"
 )
 
 // retrieveToken grabs the token from the command line arguments (os.Args) when
@@ -24,10 +23,7 @@ func retrieveToken() (token string, err error) {
 			return "", errors.New("Invalid format")
 		}
 
-		content, err := os.ReadFile(os.Args[2])
-		if err == nil {
-			token = strings.TrimSpace(string(content))
-		}
+		token, err = readToken(os.Args[2])
 
 	default:
 		err = errors.New("Too many arguments")
@@ -0,0 +1,133 @@
+package robot
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+// secretTokenHeader is the header Telegram uses to send the secret token on every webhook request
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookConfig contains the information needed to receive the updates via webhook instead of long polling
+type WebhookConfig struct {
+	URL                string // Public HTTPS URL where Telegram will send the updates, ex: "https://example.com/parrbot"
+	ListenAddr         string // Local address of the server, by default is the port of the URL (or 443 if missing, only with CertFile and KeyFile)
+	CertFile, KeyFile  string // Certificate and private key of the HTTPS server. When missing, the server uses plain HTTP and must be behind a TLS-terminating proxy
+	SecretToken        string // If given, requests that don't carry it on the X-Telegram-Bot-Api-Secret-Token header will be refused
+	DropPendingUpdates bool   // When true, updates received before the start of the bot will be ignored
+	SkipSetWebhook     bool   // When true the URL will not be communicated to Telegram, useful to test locally or when it's set externally
+}
+
+// validate checks that the webhook configuration is usable and fill the missing ListenAddr.
+// Telegram only sends the updates over HTTPS, so a server in plain HTTP doesn't
+// get the default port 443: it's expected behind a proxy on another address
+func (w *WebhookConfig) validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" {
+		return errors.New("Webhook URL must start with https://")
+	}
+
+	if w.SecretToken != "" && !regexp.MustCompile(`^[\w\-]{1,256}$`).MatchString(w.SecretToken) {
+		return errors.New("Webhook secret token can only contain 1-256 characters A-Z, a-z, 0-9, _ and -")
+	}
+
+	if (w.CertFile == "") != (w.KeyFile == "") {
+		return errors.New("Webhook needs both the certificate and the key files to use HTTPS")
+	}
+
+	if w.ListenAddr == "" {
+		switch port := u.Port(); {
+		case port != "":
+			w.ListenAddr = net.JoinHostPort("", port)
+		case w.CertFile != "":
+			w.ListenAddr = ":443"
+		default:
+			return errors.New("Webhook listen address is required without certificate: serve it behind a TLS proxy or set the certificate and key files")
+		}
+	}
+
+	return nil
+}
+
+// path returns the path of the URL on which the server will handle the updates
+func (w WebhookConfig) path() string {
+	u, err := url.Parse(w.URL)
+	if err != nil || u.EscapedPath() == "" {
+		return "/"
+	}
+	return u.EscapedPath()
+}
+
+// webhookHandler creates the http.Handler that checks the secret token and
+// pass the incoming update to the dispatcher
+func webhookHandler(d *dispatcher, secretToken string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if secretToken != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(secretToken)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		update, err := decodeUpdate(r.Body)
+		if err != nil {
+			http.Error(w, "Invalid update", http.StatusBadRequest)
+			return
+		}
+		d.dispatch(update)
+	}
+}
+
+// listenWebhook registers the webhook on Telegram (unless SkipSetWebhook) and
+// runs the HTTP server that feeds the incoming updates into the dispatcher until ctx is done
+func (d *dispatcher) listenWebhook(ctx context.Context, webhook WebhookConfig) error {
+	if !webhook.SkipSetWebhook {
+		params := url.Values{
+			"url":                  {webhook.URL},
+			"drop_pending_updates": {strconv.FormatBool(webhook.DropPendingUpdates)},
+		}
+		if webhook.SecretToken != "" {
+			params.Set("secret_token", webhook.SecretToken)
+		}
+		if err := d.robot.client.CallContext(ctx, "setWebhook", params, nil); err != nil {
+			return err
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(webhook.path(), webhookHandler(d, webhook.SecretToken))
+	server := &http.Server{Addr: webhook.ListenAddr, Handler: mux}
+
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case <-ctx.Done():
+			server.Shutdown(context.Background())
+		case <-stopped:
+		}
+	}()
+
+	var err error
+	if webhook.CertFile != "" {
+		err = server.ListenAndServeTLS(webhook.CertFile, webhook.KeyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package robot
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DazFather/parrbot/message"
+)
+
+// newTestRobot returns a robot ready to dispatch updates to the given commands
+// without contacting Telegram. The commands must not appear on the "/" menu
+func newTestRobot(t *testing.T, commandList ...Command) (*Robot, *dispatcher) {
+	t.Helper()
+
+	var config = DefaultConfig()
+	r := newRobot(&config, message.NewClient("123:TEST"))
+	if err := r.LoadCommands(commandList); err != nil {
+		t.Fatal(err)
+	}
+	return r, newDispatcher(r)
+}
+
+func TestWebhookHandler(t *testing.T) {
+	var received = make(chan string, 4)
+	_, dsp := newTestRobot(t, Command{
+		Trigger: "/start",
+		ReplyAt: message.MESSAGE,
+		CallFunc: func(bot *Bot, update *message.Update) message.Any {
+			received <- update.Message.Text
+			return nil
+		},
+	})
+	handler := webhookHandler(dsp, "my-secret")
+
+	cases := []struct {
+		name   string
+		method string
+		secret string
+		status int
+	}{
+		{"wrong method", http.MethodGet, "my-secret", http.StatusMethodNotAllowed},
+		{"missing secret", http.MethodPost, "", http.StatusUnauthorized},
+		{"wrong secret", http.MethodPost, "other-secret", http.StatusUnauthorized},
+		{"valid", http.MethodPost, "my-secret", http.StatusOK},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			body := `{"update_id": 1, "message": {"message_id": 1, "chat": {"id": 42, "type": "private"}, "text": "/start"}}`
+			req := httptest.NewRequest(c.method, "/parrbot", strings.NewReader(body))
+			if c.secret != "" {
+				req.Header.Set(secretTokenHeader, c.secret)
+			}
+
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if rec.Code != c.status {
+				t.Fatalf("status = %d, want %d", rec.Code, c.status)
+			}
+		})
+	}
+
+	dsp.inflight.Wait()
+	select {
+	case text := <-received:
+		if text != "/start" {
+			t.Errorf("handler received %q, want /start", text)
+		}
+	default:
+		t.Error("handler not called by the valid request")
+	}
+	if len(received) != 0 {
+		t.Error("handler called by a refused request")
+	}
+}
+
+func TestWebhookHandlerInvalidUpdate(t *testing.T) {
+	_, dsp := newTestRobot(t)
+
+	rec := httptest.NewRecorder()
+	webhookHandler(dsp, "")(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not json")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestWebhookValidate(t *testing.T) {
+	cases := []struct {
+		url, cert, key, listen string
+		valid                  bool
+	}{
+		{"https://example.com/parrbot", "cert.pem", "key.pem", ":443", true},
+		{"https://example.com:8443/parrbot", "cert.pem", "key.pem", ":8443", true},
+		{"https://example.com:8443/parrbot", "", "", ":8443", true},
+		{"https://example.com/parrbot", "", "", "", false}, // plain HTTP needs a proxy, not on 443
+		{"https://example.com/parrbot", "cert.pem", "", "", false},
+		{"http://example.com/parrbot", "", "", "", false},
+		{"example.com/parrbot", "", "", "", false},
+	}
+	for _, c := range cases {
+		webhook := WebhookConfig{URL: c.url, CertFile: c.cert, KeyFile: c.key}
+		err := webhook.validate()
+		if (err == nil) != c.valid {
+			t.Errorf("validate(%q) error = %v, want valid = %t", c.url, err, c.valid)
+		}
+		if webhook.ListenAddr != c.listen {
+			t.Errorf("validate(%q) ListenAddr = %q, want %q", c.url, webhook.ListenAddr, c.listen)
+		}
+	}
+}
+
+// writeCertificate writes a self-signed certificate for 127.0.0.1 and its key
+// in the temporary directory of the test, returning their paths
+func writeCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestListenWebhookTLS(t *testing.T) {
+	var received = make(chan string, 1)
+	_, dsp := newTestRobot(t, Command{
+		Trigger: "/start",
+		ReplyAt: message.MESSAGE,
+		CallFunc: func(bot *Bot, update *message.Update) message.Any {
+			received <- update.Message.Text
+			return nil
+		},
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	certFile, keyFile := writeCertificate(t)
+	webhook := WebhookConfig{URL: "https://" + addr + "/parrbot", CertFile: certFile, KeyFile: keyFile, SkipSetWebhook: true}
+	if err = webhook.validate(); err != nil {
+		t.Fatal(err)
+	}
+	webhook.ListenAddr = addr
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- dsp.listenWebhook(ctx, webhook) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	body := `{"update_id": 1, "message": {"message_id": 1, "chat": {"id": 42, "type": "private"}, "text": "/start"}}`
+	for attempt := 0; ; attempt++ {
+		res, err := client.Post(webhook.URL, "application/json", strings.NewReader(body))
+		if err == nil {
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusOK)
+			}
+			break
+		}
+		if attempt == 50 {
+			t.Fatalf("webhook not served over HTTPS: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case text := <-received:
+		if text != "/start" {
+			t.Errorf("handler received %q, want /start", text)
+		}
+	case <-time.After(time.Second):
+		t.Error("handler not called by the update sent over HTTPS")
+	}
+}
@@ -0,0 +1,136 @@
+package tgui // TeleGram User Interface or Toolkit for Graphical User Interface
+
+import (
+	"strings"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/robot"
+)
+
+// HelpPageSize is the max number of commands listed on a page of the help
+// created by HelpCommand. When there are more, the help becomes a PagedMenu
+var HelpPageSize = 10
+
+// HelpCommand creates a robot.Command with given trigger and description that
+// lists the commands of commandList visible on the "/" menu (see Command.Visible)
+// followed by their description, translated in the language of the user when
+// possible. Commands are grouped by Category, the ones without it come first
+// together with the help itself. Each user only sees the commands allowed by
+// their Roles, Scopes and Chats (see Command.VisibleTo) and when they are more
+// than HelpPageSize, they are shown as a PagedMenu
+func HelpCommand(trigger, description string, commandList []robot.Command) robot.Command {
+	var (
+		self    = robot.Command{Trigger: trigger, Description: description, ReplyAt: message.MESSAGE}
+		grouped = groupCommands(self, commandList)
+	)
+
+	return robot.Command{
+		Description: description,
+		Trigger:     trigger,
+		ReplyAt:     message.MESSAGE + message.CALLBACK_QUERY,
+		CallFunc: func(bot *robot.Bot, update *message.Update) message.Any {
+			var lang string
+			if sender := update.Sender(); sender != nil {
+				lang = sender.LanguageCode
+			}
+
+			// Pages are made only with the commands available to the user, so none is empty
+			pages := helpPages(availableTo(bot, update, grouped), lang)
+			if len(pages) > 1 {
+				return UseMenu(&PagedMenu{Pages: pages}, trigger, description).CallFunc(bot, update)
+			}
+
+			content, opt := pages[0](bot, update)
+			if _, err := ShowMessage(*update, content, opt); err != nil {
+				bot.Logger().Error("Unable to show the help", "chat_id", bot.ChatID, "update_id", update.ID, "trigger", trigger, "error", err)
+			}
+			return nil
+		},
+	}
+}
+
+// groupCommands returns the help command followed by the visible commands without
+// a category and then the others grouped by category, in order of appearance
+func groupCommands(help robot.Command, commandList []robot.Command) []robot.Command {
+	var (
+		categories []string
+		byCategory = map[string][]robot.Command{"": {help}}
+	)
+
+	for _, cmd := range commandList {
+		if !cmd.Visible() {
+			continue
+		}
+		if _, found := byCategory[cmd.Category]; !found {
+			categories = append(categories, cmd.Category)
+		}
+		byCategory[cmd.Category] = append(byCategory[cmd.Category], cmd)
+	}
+
+	grouped := byCategory[""]
+	for _, category := range categories {
+		grouped = append(grouped, byCategory[category]...)
+	}
+	return grouped
+}
+
+// helpPages splits the commands in pages of at most HelpPageSize commands,
+// written in the given language
+func helpPages(commandList []robot.Command, lang string) (pages []Page) {
+	size := HelpPageSize
+	if size <= 0 {
+		size = len(commandList)
+	}
+
+	for start := 0; start < len(commandList); start += size {
+		end := start + size
+		if end > len(commandList) {
+			end = len(commandList)
+		}
+		pages = append(pages, StaticPage(helpText(commandList[start:end], lang), nil))
+	}
+	return
+}
+
+// availableTo returns the commands that the sender of the update can use on its
+// chat, see robot.Command.VisibleTo
+func availableTo(bot *robot.Bot, update *message.Update, commandList []robot.Command) (available []robot.Command) {
+	for _, cmd := range commandList {
+		if cmd.VisibleTo(bot, update) {
+			available = append(available, cmd)
+		}
+	}
+	return
+}
+
+// helpText lists the commands, one per line, with their description in the given
+// language. The name of the category is written before its first command
+func helpText(commandList []robot.Command, lang string) string {
+	var b strings.Builder
+
+	for i, cmd := range commandList {
+		if cmd.Category != "" && (i == 0 || commandList[i-1].Category != cmd.Category) {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString("📂 " + cmd.Category + "\n")
+		}
+
+		trigger := cmd.Trigger
+		if cmd.IgnoreCase {
+			trigger = strings.ToLower(trigger)
+		}
+		b.WriteString(trigger)
+
+		description := cmd.Descriptions[lang]
+		if description == "" {
+			description = cmd.Description
+		}
+		if description != "" {
+			b.WriteString(" - " + description)
+		}
+		b.WriteString("\n")
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
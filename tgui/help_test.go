@@ -0,0 +1,142 @@
+package tgui_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DazFather/parrbot/message"
+	"github.com/DazFather/parrbot/parrbottest"
+	"github.com/DazFather/parrbot/robot"
+	"github.com/DazFather/parrbot/tgui"
+
+	"github.com/NicoNex/echotron/v3"
+)
+
+func ExampleHelpCommand() {
+	commands := []robot.Command{
+		tgui.Replier("/start", "Start the bot", "Hello!", nil),
+		{
+			Trigger:      "/ping",
+			Description:  "Check if the bot is alive",
+			Descriptions: map[string]string{"it": "Controlla se il bot è attivo"},
+			ReplyAt:      message.MESSAGE,
+			Category:     "Utilities",
+			CallFunc:     tgui.Sender(message.Text{Text: "pong"}),
+		},
+		{Trigger: "/secret", ReplyAt: message.MESSAGE, CallFunc: tgui.Sender(message.Text{Text: "🤫"})},
+	}
+	help := tgui.HelpCommand("/help", "Show what the bot can do", commands)
+
+	d, err := parrbottest.Start(robot.DefaultConfig(), append(commands, help)...)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer d.Close()
+
+	d.SendMessage(42, "/help")
+	sent, _ := d.LastMessage(42)
+	fmt.Println(sent.Text)
+	// Output:
+	// /help - Show what the bot can do
+	// /start - Start the bot
+	//
+	// 📂 Utilities
+	// /ping - Check if the bot is alive
+}
+
+func TestHelpCommandAvailable(t *testing.T) {
+	reply := tgui.Sender(message.Text{Text: "ok"})
+	commands := []robot.Command{
+		{
+			Trigger:      "/ping",
+			Description:  "Check if the bot is alive",
+			Descriptions: map[string]string{"it": "", "es": "Comprueba si el bot está activo"},
+			ReplyAt:      message.MESSAGE,
+			CallFunc:     reply,
+		},
+		{Trigger: "/ban", Description: "Ban a user", ReplyAt: message.MESSAGE, Roles: robot.ADMIN, CallFunc: reply},
+		{Trigger: "/warn", Description: "Warn a user", ReplyAt: message.MESSAGE, Roles: robot.CHAT_ADMIN, CallFunc: reply},
+		{Trigger: "/settings", Description: "Your settings", ReplyAt: message.MESSAGE, Scopes: []echotron.BotCommandScope{robot.PrivateChatsScope}, CallFunc: reply},
+		{Trigger: "/rules", Description: "Group rules", ReplyAt: message.MESSAGE, Scopes: []echotron.BotCommandScope{robot.GroupChatsScope}, CallFunc: reply},
+		{Trigger: "/pin", Description: "Pin a message", ReplyAt: message.MESSAGE, Scopes: []echotron.BotCommandScope{robot.GroupAdminsScope}, CallFunc: reply},
+		{Trigger: "/vip", Description: "VIP corner", ReplyAt: message.MESSAGE, Scopes: []echotron.BotCommandScope{robot.ChatMemberScope(-100, 7)}, CallFunc: reply},
+		{Trigger: "/poll", Description: "Start a poll", ReplyAt: message.MESSAGE, Chats: message.GROUP_CHAT + message.SUPERGROUP_CHAT, CallFunc: reply},
+	}
+
+	config := robot.DefaultConfig()
+	config.AdminIDs = []int64{7}
+	d, err := parrbottest.Start(config, append(commands, tgui.HelpCommand("/help", "Show what the bot can do", commands))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	d.Handle("getChatMember", func(call parrbottest.Call) (interface{}, error) {
+		status := "member"
+		if call.ChatID() == -100 && call.Params.Get("user_id") == "7" {
+			status = "administrator"
+		}
+		return echotron.ChatMember{User: &echotron.User{ID: 7}, Status: status}, nil
+	})
+
+	cases := []struct {
+		name   string
+		chatID int64
+		user   echotron.User
+		want   string
+	}{
+		{"private", 42, echotron.User{ID: 42}, "/help - Show what the bot can do\n/ping - Check if the bot is alive\n/settings - Your settings"},
+		{"private admin", 7, echotron.User{ID: 7}, "/help - Show what the bot can do\n/ping - Check if the bot is alive\n/ban - Ban a user\n/settings - Your settings"},
+		{"empty translation", 42, echotron.User{ID: 42, LanguageCode: "it"}, "/help - Show what the bot can do\n/ping - Check if the bot is alive\n/settings - Your settings"},
+		{"translation", 42, echotron.User{ID: 42, LanguageCode: "es"}, "/help - Show what the bot can do\n/ping - Comprueba si el bot está activo\n/settings - Your settings"},
+		{"group", -100, echotron.User{ID: 42}, "/help - Show what the bot can do\n/ping - Check if the bot is alive\n/rules - Group rules\n/poll - Start a poll"},
+		{"group admin", -100, echotron.User{ID: 7}, "/help - Show what the bot can do\n/ping - Check if the bot is alive\n/ban - Ban a user\n/warn - Warn a user\n/rules - Group rules\n/pin - Pin a message\n/vip - VIP corner\n/poll - Start a poll"},
+	}
+
+	for _, c := range cases {
+		chat := echotron.Chat{ID: c.chatID, Type: "private"}
+		if c.chatID < 0 {
+			chat.Type = "supergroup"
+		}
+		err := d.Update(&echotron.Update{Message: &echotron.Message{From: &c.user, Chat: chat, Text: "/help"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sent, _ := d.LastMessage(c.chatID); sent.Text != c.want {
+			t.Errorf("%s help:\n%s\nwant:\n%s", c.name, sent.Text, c.want)
+		}
+	}
+}
+
+func TestHelpCommandRestrictedPage(t *testing.T) {
+	defer func(size int) { tgui.HelpPageSize = size }(tgui.HelpPageSize)
+	tgui.HelpPageSize = 2
+
+	reply := tgui.Sender(message.Text{Text: "ok"})
+	commands := []robot.Command{
+		{Trigger: "/a", Description: "Everyone", ReplyAt: message.MESSAGE, CallFunc: reply},
+		{Trigger: "/b", Description: "Admins", ReplyAt: message.MESSAGE, Roles: robot.ADMIN, CallFunc: reply},
+		{Trigger: "/c", Description: "Admins too", ReplyAt: message.MESSAGE, Roles: robot.ADMIN, CallFunc: reply},
+	}
+
+	config := robot.DefaultConfig()
+	config.AdminIDs = []int64{7}
+	d, err := parrbottest.Start(config, append(commands, tgui.HelpCommand("/help", "Help", commands))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	// The second page only has commands for the admins, so the user has just one
+	d.SendMessage(42, "/help 1")
+	if sent, _ := d.LastMessage(42); sent.Text != "/help - Help\n/a - Everyone" {
+		t.Errorf("user help page 1:\n%s\nwant the only page available to the user", sent.Text)
+	} else if sent.ReplyMarkup != nil && len(sent.ReplyMarkup.InlineKeyboard) > 0 {
+		t.Errorf("user help has the navigation buttons %+v, want a single page", sent.ReplyMarkup.InlineKeyboard)
+	}
+
+	d.SendMessage(7, "/help 1")
+	if sent, _ := d.LastMessage(7); sent.Text != "/b - Admins\n/c - Admins too" {
+		t.Errorf("admin help page 1:\n%s\nwant the commands of the admins", sent.Text)
+	}
+}
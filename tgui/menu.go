@@ -2,7 +2,6 @@ package tgui // TeleGram User Interface or Toolkit for Graphical User Interface
 
 import (
 	"errors"
-	"log"
 	"strconv"
 	"strings"
 
@@ -54,7 +53,9 @@ func UseMenu(menu Menu, trigger, description string) robot.Command {
 		} else {
 			payload = update.CallbackQuery.Data
 		}
-		payload = strings.TrimSpace(strings.TrimPrefix(payload, trigger))
+		// Remove the trigger, that can also be one of the aliases of the command
+		_, payload, _ = strings.Cut(strings.TrimSpace(payload), " ")
+		payload = strings.TrimSpace(payload)
 
 		// Select menu's page
 		switch payload {
@@ -79,14 +80,14 @@ func UseMenu(menu Menu, trigger, description string) robot.Command {
 			p, err := menu.Select(payload)
 			if err != nil {
 				collapse(update, "⚠️ Invalid page: retry to send "+trigger)
-				log.Println(err)
+				bot.Logger().Warn("Invalid menu page", "chat_id", bot.ChatID, "update_id", update.ID, "trigger", trigger, "page", payload, "error", err)
 				return nil
 			}
 			page = *p
 		}
 
 		if err := menu.Show(page, bot, update); err != nil {
-			log.Fatal("Error in Show:", err)
+			bot.Logger().Error("Unable to show the menu page", "chat_id", bot.ChatID, "update_id", update.ID, "trigger", trigger, "error", err)
 		}
 		return nil
 	}
@@ -173,9 +174,12 @@ func (m *PagedMenu) SelectHome() Page {
 // Select a page by it's index (converting it into an integer) and reset current
 func (m *PagedMenu) Select(pageIndex string) (*Page, error) {
 	n, err := strconv.Atoi(pageIndex)
-	if n >= 0 && n < len(m.Pages) && err != nil {
+	if err != nil {
 		return nil, err
 	}
+	if n < 0 || n >= len(m.Pages) {
+		return nil, errors.New("Invalid page index")
+	}
 	m.current = n
 	return &m.Pages[n], nil
 }
@@ -2,7 +2,6 @@ package tgui // TeleGram User Interface or Toolkit for Graphical User Interface
 
 import (
 	"errors"
-	"log"
 	"regexp"
 
 	"github.com/DazFather/parrbot/message"
@@ -23,7 +22,7 @@ func ShowMessage(u message.Update, text string, opt *EditOptions) (sent *message
 	}
 
 	if original := u.FromMessage(); original != nil && original.Chat != nil {
-		return message.Text{text, ToMessageOptions(opt)}.Send(original.Chat.ID)
+		return u.Client().Send(message.Text{text, ToMessageOptions(opt)}, original.Chat.ID)
 	}
 
 	return nil, errors.New("Invalid given update")
@@ -45,7 +44,7 @@ func Replier(trigger, description string, text string, opt *EditOptions) robot.C
 		ReplyAt:     message.MESSAGE + message.CALLBACK_QUERY,
 		CallFunc: func(bot *robot.Bot, update *message.Update) message.Any {
 			if _, err := ShowMessage(*update, text, opt); err != nil {
-				log.Fatal(err)
+				bot.Logger().Error("Unable to show the message", "chat_id", bot.ChatID, "update_id", update.ID, "trigger", trigger, "error", err)
 			}
 			return nil
 		},